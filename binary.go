@@ -0,0 +1,176 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const binaryTABLE_BITS = 16
+
+// tBinary fills buffers with random bytes. Bytes are uniformly
+// distributed, if table is nil. Otherwise a byte is looked up in table
+// by 16 random bits, i.e. table is the distribution.
+type tBinary struct {
+	table []byte
+}
+
+func interpretBinary(params *tParameters) (tFormat, error) {
+	var err error
+	format := new(tBinary)
+	if params.weights.Available() && params.entropy.Available() {
+		return nil, errors.New("weights and entropy are exclusive")
+	} else if params.weights.Available() {
+		var weights []float64
+		weights, err = parseByteWeights(params.weights.Values[0])
+		if err == nil {
+			format.table = newByteTable(weights)
+		}
+	} else if params.entropy.Available() {
+		var entropy float64
+		entropy, err = strconv.ParseFloat(params.entropy.Values[0], 64)
+		if err != nil || entropy < 0 || entropy > 8 {
+			return nil, errors.New("can't parse entropy (expected 0 to 8 bits per byte)")
+		}
+		if entropy < 8 {
+			format.table = newByteTable(entropyWeights(entropy, 256))
+		}
+	}
+	if err == nil {
+		return format, nil
+	}
+	return nil, err
+}
+
+// parseByteWeights parses "byte:weight,...", where byte is a value or a
+// range, e.g. "0-31:1,65:10". Bytes not listed have weight 0.
+func parseByteWeights(str string) ([]float64, error) {
+	var total float64
+	weights := make([]float64, 256)
+	for _, pair := range strings.Split(str, ",") {
+		byteWeight := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		bounds := strings.SplitN(byteWeight[0], "-", 2)
+		first, errFirst := strconv.ParseUint(bounds[0], 0, 8)
+		last, errLast := first, errFirst
+		if len(bounds) == 2 {
+			last, errLast = strconv.ParseUint(bounds[1], 0, 8)
+		}
+		if errFirst != nil || errLast != nil || first > last {
+			return nil, errors.New("can't parse byte \"" + byteWeight[0] + "\" (expected 0 to 255)")
+		}
+		weight := 1.0
+		if len(byteWeight) == 2 {
+			var err error
+			weight, err = strconv.ParseFloat(byteWeight[1], 64)
+			if err != nil || weight < 0 {
+				return nil, errors.New("can't parse weight of \"" + byteWeight[0] + "\"")
+			}
+		}
+		for i := first; i <= last; i++ {
+			total += weight - weights[i]
+			weights[i] = weight
+		}
+	}
+	if total > 0 {
+		return weights, nil
+	}
+	return nil, errors.New("sum of weights is zero")
+}
+
+// entropyWeights returns n weights with Shannon entropy in bits. Weights
+// decrease exponentially, the rate is found by bisection.
+func entropyWeights(entropy float64, n int) []float64 {
+	weights := make([]float64, n)
+	lower, upper := 0.0, 64.0
+	for i := 0; i < 64; i++ {
+		rate := (lower + upper) / 2
+		for j := range weights {
+			weights[j] = math.Exp(-rate * float64(j))
+		}
+		if shannonEntropy(weights) > entropy {
+			lower = rate
+		} else {
+			upper = rate
+		}
+	}
+	return weights
+}
+
+// shannonEntropy returns the entropy of weights in bits.
+func shannonEntropy(weights []float64) float64 {
+	var total, entropy float64
+	for _, weight := range weights {
+		total += weight
+	}
+	for _, weight := range weights {
+		if weight > 0 {
+			p := weight / total
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// newByteTable returns table of 2^16 bytes, where bytes occur
+// proportional to weights (largest remainder method).
+func newByteTable(weights []float64) []byte {
+	var total float64
+	size := 1 << binaryTABLE_BITS
+	counts := make([]int, len(weights))
+	remainders := make([]float64, len(weights))
+	for _, weight := range weights {
+		total += weight
+	}
+	filled := 0
+	for i, weight := range weights {
+		exact := weight / total * float64(size)
+		counts[i] = int(exact)
+		remainders[i] = exact - float64(counts[i])
+		filled += counts[i]
+	}
+	for ; filled < size; filled++ {
+		largest := 0
+		for i, remainder := range remainders {
+			if remainder > remainders[largest] {
+				largest = i
+			}
+		}
+		counts[largest]++
+		remainders[largest] = -1
+	}
+	table := make([]byte, 0, size)
+	for i, count := range counts {
+		for j := 0; j < count; j++ {
+			table = append(table, byte(i))
+		}
+	}
+	return table
+}
+
+func (binary *tBinary) fill(generator *tGenerator, newLine []byte) {
+	if binary.table == nil {
+		generator.random.Read(generator.bytes)
+	} else {
+		bytes := generator.bytes
+		for len(bytes) >= 4 {
+			bits := generator.random.Uint64()
+			bytes[0] = binary.table[bits&0xffff]
+			bytes[1] = binary.table[bits>>16&0xffff]
+			bytes[2] = binary.table[bits>>32&0xffff]
+			bytes[3] = binary.table[bits>>48]
+			bytes = bytes[4:]
+		}
+		for i := range bytes {
+			bytes[i] = binary.table[generator.random.Intn(len(binary.table))]
+		}
+	}
+	generator.written = len(generator.bytes)
+}
@@ -0,0 +1,55 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseByteWeights(t *testing.T) {
+	weights, err := parseByteWeights("0-9:2,5,0x41:0.5")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if weights[0] != 2 || weights[5] != 1 || weights[9] != 2 || weights[10] != 0 || weights[65] != 0.5 {
+		t.Error("wrong weights:", weights[:11], weights[65])
+	}
+	for _, str := range []string{"256", "9-0", "a:1", "1:-1", "1:0"} {
+		if _, err = parseByteWeights(str); err == nil {
+			t.Error("no error for", str)
+		}
+	}
+}
+
+func TestBinaryEntropy(t *testing.T) {
+	for _, entropy := range []float64{0.5, 3.5, 7.9} {
+		format := &tBinary{newByteTable(entropyWeights(entropy, 256))}
+		output := generateTest(format, 1<<20, 1<<16, []byte{'\n'})
+		counts := make([]float64, 256)
+		for _, b := range output {
+			counts[b]++
+		}
+		if actual := shannonEntropy(counts); math.Abs(actual-entropy) > 0.02 {
+			t.Error("entropy", entropy, "is", actual)
+		}
+	}
+}
+
+func TestBinaryUniform(t *testing.T) {
+	output := generateTest(new(tBinary), 1<<16, 1<<12, []byte{'\n'})
+	counts := make([]int, 256)
+	for _, b := range output {
+		counts[b]++
+	}
+	for i, count := range counts {
+		if count == 0 {
+			t.Error("byte missing:", i)
+		}
+	}
+}
@@ -0,0 +1,508 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	codeDEPTH_DEFAULT  = 3
+	codeFANOUT_DEFAULT = 6
+	codeEXPR_DEPTH     = 3
+	codeCOMMENT_MAX    = 80
+)
+
+const (
+	langC = iota
+	langGO
+	langJS
+)
+
+// tCodeFormat generates source code of C, Go or JavaScript. Records are
+// top-level declarations, mostly functions. Records are stretched by line
+// comments following the declaration.
+type tCodeFormat struct {
+	lang     int
+	maxDepth int
+	fanout   int
+	indent   string
+}
+
+var codeTypesC = []string{"int", "long", "double", "char *", "unsigned int", "size_t", "bool"}
+var codeTypesGo = []string{"int", "int64", "float64", "string", "bool", "[]byte", "error"}
+var codeEscapes = []string{"\\n", "\\t", "\\r", "\\\\", "\\\"", "\\x41", "\\x7f"}
+var codeOperators = []string{" + ", " - ", " * ", " / ", " % ", " == ", " != ", " < ", " >= ", " && ", " || ", " & ", " | ", " << "}
+
+func interpretCode(params *tParameters) (tFormat, error) {
+	var err error
+	format := new(tCodeFormat)
+	format.maxDepth, err = interpretDepth(params, codeDEPTH_DEFAULT, err)
+	format.fanout, err = interpretFanout(params, codeFANOUT_DEFAULT, err)
+	if err == nil {
+		lang := "c"
+		if params.lang.Available() {
+			lang = strings.ToLower(params.lang.Values[0])
+		}
+		switch lang {
+		case "c":
+			format.lang, format.indent = langC, "    "
+		case "go":
+			format.lang, format.indent = langGO, "\t"
+		case "js", "javascript":
+			format.lang, format.indent = langJS, "  "
+		default:
+			return nil, errors.New("unknown language \"" + params.lang.Values[0] + "\" (expected c, go or js)")
+		}
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+func (format *tCodeFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	switch format.lang {
+	case langC:
+		dst = appendLines(dst, newLine, "#include <stdbool.h>", "#include <stdio.h>", "#include <stdlib.h>", "")
+	case langGO:
+		dst = appendLines(dst, newLine, "package main", "", "import (", "\t\"fmt\"", "\t\"strings\"", ")", "")
+	case langJS:
+		dst = appendLines(dst, newLine, "'use strict';", "")
+	}
+	return dst
+}
+
+func (format *tCodeFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	switch generator.random.Intn(8) {
+	case 0:
+		dst = format.appendType(dst, generator, newLine)
+	case 1:
+		dst = format.appendGlobal(dst, generator)
+	default:
+		dst = format.appendFunction(dst, generator, newLine)
+	}
+	dst = append(dst, newLine...)
+	generator.markStretch(len(dst), fillLineComments(newLine, generator.wordFill()))
+	return append(dst, newLine...)
+}
+
+func (format *tCodeFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func appendLines(dst, newLine []byte, lines ...string) []byte {
+	for _, line := range lines {
+		dst = append(dst, line...)
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+func (format *tCodeFormat) appendFunction(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if generator.random.Intn(3) == 0 {
+		dst = format.appendComment(dst, generator)
+		dst = append(dst, newLine...)
+	}
+	parameters := generator.random.Intn(4)
+	switch format.lang {
+	case langC:
+		if generator.random.Intn(2) == 0 {
+			dst = append(dst, "static "...)
+		}
+		dst = append(dst, codeTypesC[generator.random.Intn(len(codeTypesC))]...)
+		dst = appendSpaceUnlessPointer(dst)
+	case langGO:
+		dst = append(dst, "func "...)
+	case langJS:
+		dst = append(dst, "function "...)
+	}
+	dst = format.appendIdentifier(dst, generator)
+	dst = append(dst, '(')
+	for i := 0; i < parameters; i++ {
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+		if format.lang == langC {
+			dst = append(dst, codeTypesC[generator.random.Intn(len(codeTypesC))]...)
+			dst = appendSpaceUnlessPointer(dst)
+		}
+		dst = format.appendIdentifier(dst, generator)
+		if format.lang == langGO {
+			dst = append(dst, ' ')
+			dst = append(dst, codeTypesGo[generator.random.Intn(len(codeTypesGo))]...)
+		}
+	}
+	dst = append(dst, ')')
+	if format.lang == langGO && generator.random.Intn(2) == 0 {
+		dst = append(dst, ' ')
+		dst = append(dst, codeTypesGo[generator.random.Intn(len(codeTypesGo))]...)
+	}
+	return format.appendBlock(dst, generator, newLine, 1)
+}
+
+// appendType appends a struct (C, Go) or class (JavaScript).
+func (format *tCodeFormat) appendType(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	fields := 1 + generator.random.Intn(format.fanout)
+	switch format.lang {
+	case langC:
+		dst = append(dst, "struct "...)
+		dst = format.appendIdentifier(dst, generator)
+		dst = append(dst, " {"...)
+		for i := 0; i < fields; i++ {
+			dst = append(dst, newLine...)
+			dst = append(dst, format.indent...)
+			dst = append(dst, codeTypesC[generator.random.Intn(len(codeTypesC))]...)
+			dst = appendSpaceUnlessPointer(dst)
+			dst = format.appendIdentifier(dst, generator)
+			dst = append(dst, ';')
+		}
+		dst = append(dst, newLine...)
+		return append(dst, "};"...)
+	case langGO:
+		dst = append(dst, "type "...)
+		dst = format.appendTypeName(dst, generator)
+		dst = append(dst, " struct {"...)
+		for i := 0; i < fields; i++ {
+			dst = append(dst, newLine...)
+			dst = append(dst, format.indent...)
+			dst = format.appendIdentifier(dst, generator)
+			dst = append(dst, ' ')
+			dst = append(dst, codeTypesGo[generator.random.Intn(len(codeTypesGo))]...)
+		}
+		dst = append(dst, newLine...)
+		return append(dst, '}')
+	}
+	dst = append(dst, "class "...)
+	dst = format.appendTypeName(dst, generator)
+	dst = append(dst, " {"...)
+	dst = append(dst, newLine...)
+	dst = append(dst, format.indent...)
+	dst = append(dst, "constructor("...)
+	offset := len(dst)
+	for i := 0; i < fields; i++ {
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+		dst = format.appendIdentifier(dst, generator)
+	}
+	names := strings.Split(string(dst[offset:]), ", ")
+	dst = append(dst, ") {"...)
+	for _, name := range names {
+		dst = append(dst, newLine...)
+		dst = append(dst, format.indent...)
+		dst = append(dst, format.indent...)
+		dst = append(dst, "this."...)
+		dst = append(dst, name...)
+		dst = append(dst, " = "...)
+		dst = append(dst, name...)
+		dst = append(dst, ';')
+	}
+	dst = append(dst, newLine...)
+	dst = append(dst, format.indent...)
+	dst = append(dst, '}')
+	dst = append(dst, newLine...)
+	return append(dst, '}')
+}
+
+// appendGlobal appends a global constant or variable.
+func (format *tCodeFormat) appendGlobal(dst []byte, generator *tGenerator) []byte {
+	switch format.lang {
+	case langC:
+		if generator.random.Intn(2) == 0 {
+			dst = append(dst, "#define "...)
+			dst = append(dst, strings.ToUpper(string(format.appendIdentifier(nil, generator)))...)
+			dst = append(dst, ' ')
+			return format.appendLiteral(dst, generator)
+		}
+		dst = append(dst, "static const "...)
+		dst = append(dst, codeTypesC[generator.random.Intn(len(codeTypesC))]...)
+		dst = appendSpaceUnlessPointer(dst)
+	case langGO:
+		dst = append(dst, [...]string{"var ", "const "}[generator.random.Intn(2)]...)
+	case langJS:
+		dst = append(dst, "const "...)
+	}
+	dst = format.appendIdentifier(dst, generator)
+	dst = append(dst, " = "...)
+	dst = format.appendLiteral(dst, generator)
+	return format.appendTerminator(dst)
+}
+
+// appendBlock appends braces with statements. Closing brace is indented
+// at depth - 1.
+func (format *tCodeFormat) appendBlock(dst []byte, generator *tGenerator, newLine []byte, depth int) []byte {
+	dst = append(dst, " {"...)
+	for i := 1 + generator.random.Intn(format.fanout); i > 0; i-- {
+		dst = append(dst, newLine...)
+		dst = format.appendIndent(dst, depth)
+		dst = format.appendStatement(dst, generator, newLine, depth)
+	}
+	dst = append(dst, newLine...)
+	dst = format.appendIndent(dst, depth-1)
+	return append(dst, '}')
+}
+
+func (format *tCodeFormat) appendStatement(dst []byte, generator *tGenerator, newLine []byte, depth int) []byte {
+	kinds := 6
+	if depth < format.maxDepth {
+		kinds = 9
+	}
+	switch generator.random.Intn(kinds) {
+	case 0:
+		// declaration
+		switch format.lang {
+		case langC:
+			dst = append(dst, codeTypesC[generator.random.Intn(len(codeTypesC))]...)
+			dst = appendSpaceUnlessPointer(dst)
+			dst = format.appendIdentifier(dst, generator)
+			dst = append(dst, " = "...)
+		case langGO:
+			dst = format.appendIdentifier(dst, generator)
+			dst = append(dst, " := "...)
+		case langJS:
+			dst = append(dst, [...]string{"let ", "const "}[generator.random.Intn(2)]...)
+			dst = format.appendIdentifier(dst, generator)
+			dst = append(dst, " = "...)
+		}
+		dst = format.appendExpression(dst, generator, 1)
+	case 1:
+		dst = format.appendIdentifier(dst, generator)
+		dst = append(dst, [...]string{" = ", " += ", " -= ", " *= "}[generator.random.Intn(4)]...)
+		dst = format.appendExpression(dst, generator, 1)
+	case 2:
+		switch format.lang {
+		case langC:
+			dst = append(dst, "printf("...)
+		case langGO:
+			dst = append(dst, "fmt.Println("...)
+		case langJS:
+			dst = append(dst, "console.log("...)
+		}
+		dst = format.appendString(dst, generator)
+		dst = append(dst, ", "...)
+		dst = format.appendExpression(dst, generator, 1)
+		dst = append(dst, ')')
+	case 3:
+		dst = format.appendCall(dst, generator, 1)
+	case 4:
+		return format.appendComment(dst, generator)
+	case 5:
+		dst = append(dst, "return "...)
+		dst = format.appendExpression(dst, generator, 1)
+	case 6:
+		dst = append(dst, "if "...)
+		dst = format.appendCondition(dst, generator)
+		dst = format.appendBlock(dst, generator, newLine, depth+1)
+		if generator.random.Intn(2) == 0 {
+			dst = append(dst, " else"...)
+			dst = format.appendBlock(dst, generator, newLine, depth+1)
+		}
+		return dst
+	case 7:
+		dst = append(dst, "for "...)
+		if format.lang != langGO {
+			dst = append(dst, '(')
+		}
+		switch format.lang {
+		case langC:
+			dst = append(dst, "int i = 0; i < "...)
+		case langGO:
+			dst = append(dst, "i := 0; i < "...)
+		case langJS:
+			dst = append(dst, "let i = 0; i < "...)
+		}
+		dst = format.appendExpression(dst, generator, codeEXPR_DEPTH)
+		dst = append(dst, "; i++"...)
+		if format.lang != langGO {
+			dst = append(dst, ')')
+		}
+		return format.appendBlock(dst, generator, newLine, depth+1)
+	default:
+		if format.lang == langGO {
+			dst = append(dst, "for "...)
+		} else {
+			dst = append(dst, "while "...)
+		}
+		dst = format.appendCondition(dst, generator)
+		return format.appendBlock(dst, generator, newLine, depth+1)
+	}
+	return format.appendTerminator(dst)
+}
+
+// appendCondition appends an expression, in parentheses for C and
+// JavaScript.
+func (format *tCodeFormat) appendCondition(dst []byte, generator *tGenerator) []byte {
+	if format.lang == langGO {
+		return format.appendExpression(dst, generator, 1)
+	}
+	dst = append(dst, '(')
+	dst = format.appendExpression(dst, generator, 1)
+	return append(dst, ')')
+}
+
+func (format *tCodeFormat) appendExpression(dst []byte, generator *tGenerator, depth int) []byte {
+	kinds := 4
+	if depth < codeEXPR_DEPTH {
+		kinds = 8
+	}
+	switch generator.random.Intn(kinds) {
+	case 0, 1:
+		return format.appendIdentifier(dst, generator)
+	case 2, 3:
+		return format.appendLiteral(dst, generator)
+	case 4, 5:
+		dst = format.appendExpression(dst, generator, depth+1)
+		dst = append(dst, codeOperators[generator.random.Intn(len(codeOperators))]...)
+		return format.appendExpression(dst, generator, depth+1)
+	case 6:
+		dst = append(dst, [...]string{"(", "!(", "-("}[generator.random.Intn(3)]...)
+		dst = format.appendExpression(dst, generator, depth+1)
+		return append(dst, ')')
+	}
+	return format.appendCall(dst, generator, depth)
+}
+
+func (format *tCodeFormat) appendCall(dst []byte, generator *tGenerator, depth int) []byte {
+	if format.lang == langGO && generator.random.Intn(4) == 0 {
+		dst = append(dst, "strings."...)
+		dst = append(dst, [...]string{"ToUpper", "TrimSpace", "Title"}[generator.random.Intn(3)]...)
+	} else {
+		dst = format.appendIdentifier(dst, generator)
+	}
+	dst = append(dst, '(')
+	for i := generator.random.Intn(4); i > 0; i-- {
+		dst = format.appendExpression(dst, generator, depth+1)
+		if i > 1 {
+			dst = append(dst, ", "...)
+		}
+	}
+	return append(dst, ')')
+}
+
+// appendLiteral appends a number, string or boolean.
+func (format *tCodeFormat) appendLiteral(dst []byte, generator *tGenerator) []byte {
+	switch generator.random.Intn(6) {
+	case 0, 1:
+		return strconv.AppendInt(dst, generator.random.Int63n(1000), 10)
+	case 2:
+		dst = append(dst, '0', 'x')
+		return strconv.AppendInt(dst, generator.random.Int63n(1<<32), 16)
+	case 3:
+		return strconv.AppendFloat(dst, generator.random.Float64()*1000, [...]byte{'f', 'e'}[generator.random.Intn(2)], 1+generator.random.Intn(5), 64)
+	case 4:
+		return format.appendString(dst, generator)
+	}
+	return strconv.AppendBool(dst, generator.random.Intn(2) == 0)
+}
+
+// appendString appends a string literal with escape sequences.
+func (format *tCodeFormat) appendString(dst []byte, generator *tGenerator) []byte {
+	dst = append(dst, '"')
+	generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(5))
+	for _, b := range generator.scratch {
+		if b == '"' || b == '\\' {
+			dst = append(dst, '\\')
+		} else if b == ' ' && generator.random.Intn(4) == 0 {
+			// before space, i.e. hex escapes are never followed by digits
+			dst = append(dst, codeEscapes[generator.random.Intn(len(codeEscapes))]...)
+		}
+		dst = append(dst, b)
+	}
+	return append(dst, '"')
+}
+
+// appendComment appends a line comment or a block comment.
+func (format *tCodeFormat) appendComment(dst []byte, generator *tGenerator) []byte {
+	if generator.random.Intn(4) == 0 {
+		dst = append(dst, "/* "...)
+		for i := generator.random.Intn(8); i >= 0; i-- {
+			dst = generator.appendName(dst, 1, 10)
+			dst = append(dst, ' ')
+		}
+		return append(dst, "*/"...)
+	}
+	dst = append(dst, "// "...)
+	dst = generator.appendWords(dst, 1+generator.random.Intn(10))
+	if dst[len(dst)-1] == '\\' {
+		// line continuation in C
+		dst = append(dst, '.')
+	}
+	return dst
+}
+
+// fillLineComments returns a function, that fills bytes with lines of
+// comments of words of fill. Lines don't exceed codeCOMMENT_MAX. Bytes too
+// short for a comment are filled with spaces.
+func fillLineComments(newLine []byte, fill func(*rand.Rand, []byte)) func(*rand.Rand, []byte) {
+	return func(random *rand.Rand, bytes []byte) {
+		if len(bytes) < len("// x")+len(newLine) {
+			fillSpaces(bytes)
+			return
+		}
+		text := bytes[:len(bytes)-len(newLine)]
+		copy(bytes[len(text):], newLine)
+		for len(text) > 0 {
+			length := len(text)
+			if length-codeCOMMENT_MAX-len(newLine) >= len("// x") {
+				length = codeCOMMENT_MAX
+			}
+			copy(text, "// ")
+			fill(random, text[3:length])
+			for i := 4 + random.Intn(10); i < length-1; i += 2 + random.Intn(10) {
+				text[i] = ' '
+			}
+			text = text[length:]
+			if len(text) > 0 {
+				text = text[copy(text, newLine):]
+			}
+		}
+	}
+}
+
+// appendIdentifier appends a name in camel case or with a digit, i.e. it
+// is never a keyword.
+func (format *tCodeFormat) appendIdentifier(dst []byte, generator *tGenerator) []byte {
+	dst = generator.appendName(dst, 1, 8)
+	if generator.random.Intn(3) == 0 {
+		return strconv.AppendInt(dst, generator.random.Int63n(10), 10)
+	}
+	return format.appendTypeName(dst, generator)
+}
+
+// appendTypeName appends a name beginning with an upper case letter.
+func (format *tCodeFormat) appendTypeName(dst []byte, generator *tGenerator) []byte {
+	offset := len(dst)
+	dst = generator.appendName(dst, 2, 10)
+	dst[offset] -= 'a' - 'A'
+	return dst
+}
+
+func (format *tCodeFormat) appendIndent(dst []byte, depth int) []byte {
+	for i := 0; i < depth; i++ {
+		dst = append(dst, format.indent...)
+	}
+	return dst
+}
+
+func (format *tCodeFormat) appendTerminator(dst []byte) []byte {
+	if format.lang != langGO {
+		return append(dst, ';')
+	}
+	return dst
+}
+
+// appendSpaceUnlessPointer appends a space after a type, that isn't a
+// pointer.
+func appendSpaceUnlessPointer(dst []byte) []byte {
+	if dst[len(dst)-1] != '*' {
+		return append(dst, ' ')
+	}
+	return dst
+}
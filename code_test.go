@@ -0,0 +1,66 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestCodeGo(t *testing.T) {
+	format := &tCodeFormat{langGO, 3, 6, "\t"}
+	output := generateTest(newRecords(format), 50000, 10000, []byte{'\n'})
+	file, err := parser.ParseFile(token.NewFileSet(), "", output, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && len(ident.Name) > 19 {
+			t.Error("identifier stretched:", ident.Name)
+		}
+		return true
+	})
+}
+
+func TestCodeBraces(t *testing.T) {
+	format := &tCodeFormat{langC, 3, 6, "    "}
+	output := generateTest(newRecords(format), 50000, 10000, []byte{'\n'})
+	if !bytes.HasPrefix(output, []byte("#include <stdbool.h>\n")) {
+		t.Error("includes missing")
+	}
+	var depth int
+	for _, line := range bytes.Split(output, []byte{'\n'}) {
+		var quoted bool
+		for i := 0; i < len(line); i++ {
+			if quoted {
+				if line[i] == '\\' {
+					i++
+				} else if line[i] == '"' {
+					quoted = false
+				}
+			} else if line[i] == '"' {
+				quoted = true
+			} else if line[i] == '/' && i+1 < len(line) && (line[i+1] == '/' || line[i+1] == '*') {
+				break
+			} else if line[i] == '{' {
+				depth++
+			} else if line[i] == '}' {
+				depth--
+			}
+		}
+		if depth < 0 {
+			t.Fatal("unbalanced braces")
+		}
+	}
+	if depth != 0 {
+		t.Error("unbalanced braces:", depth)
+	}
+}
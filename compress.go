@@ -0,0 +1,141 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"compress/flate"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+const (
+	compressSEGMENT = 512
+	compressWINDOW  = 32 * 1024
+	compressSAMPLE  = 256 * 1024
+)
+
+// compressDistance keeps copies in the window of deflate.
+var compressDistance = tDistance{false, compressWINDOW/compressSEGMENT - 1}
+
+// tCompressible replaces segments of output with copies of recent
+// segments, so compressors find matches in their window (deflate has
+// 32 KiB). Segments are kept at the rate of share, i.e. share is the
+// fraction of output with the entropy of the format.
+type tCompressible struct {
+	format tFormat
+	share  float64
+}
+
+// tCounter counts bytes written to it.
+type tCounter struct {
+	n int
+}
+
+func (counter *tCounter) Write(p []byte) (int, error) {
+	counter.n += len(p)
+	return len(p), nil
+}
+
+func interpretCompress(params *tParameters, content *tContent, newLine []byte, err error) (tFormat, error) {
+	if err == nil && params.compress.Available() {
+		switch content.format.(type) {
+		case *tText, *tBinary:
+		default:
+			return nil, errors.New("compress-ratio needs text or binary output")
+		}
+		ratio, err := strconv.ParseFloat(params.compress.Values[0], 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return nil, errors.New("can't parse compress-ratio (expected 0 to 1)")
+		}
+		return newCompressible(content, ratio, newLine)
+	}
+	return content.format, err
+}
+
+// newCompressible returns format with share of kept segments, that
+// compresses at ratio with deflate. Compressed size is the sum of kept
+// and copied segments, so share is interpolated between the ratios of
+// a sample without and with copies only.
+func newCompressible(content *tContent, ratio float64, newLine []byte) (tFormat, error) {
+	format := &tCompressible{content.format, 1}
+	sample := new(tContent)
+	*sample = *content
+	sample.format = format
+	generator := newGenerator(compressSAMPLE, sample)
+	ratioMax := generator.compressRatio(newLine)
+	if ratio > ratioMax+0.01 {
+		return nil, errors.New("compress-ratio of output is at most " + strconv.FormatFloat(ratioMax, 'f', 2, 64))
+	}
+	format.share = 0
+	ratioMin := generator.compressRatio(newLine)
+	if ratio < ratioMin {
+		return nil, errors.New("compress-ratio of output is at least " + strconv.FormatFloat(ratioMin, 'f', 3, 64))
+	}
+	format.share = math.Min((ratio-ratioMin)/(ratioMax-ratioMin), 1)
+	return format, nil
+}
+
+// compressRatio returns the ratio of deflated size to size of buffer.
+// Lower levels of package flate skip matches in random data, unlike zlib
+// (gzip) at default level, so best compression is the reference.
+func (generator *tGenerator) compressRatio(newLine []byte) float64 {
+	counter := new(tCounter)
+	generator.locate(0, len(generator.bytes))
+	generator.generate(newLine)
+	writer, _ := flate.NewWriter(counter, flate.BestCompression)
+	writer.Write(generator.bytes)
+	writer.Close()
+	return float64(counter.n) / float64(len(generator.bytes))
+}
+
+func (format *tCompressible) fill(generator *tGenerator, newLine []byte) {
+	format.format.fill(generator, newLine)
+	generator.copyBlocks(compressSEGMENT, 1-format.share, &compressDistance)
+}
+
+// alphabetOf returns the characters generated by randomFill.
+func alphabetOf(randomFill func(*rand.Rand, []byte)) []byte {
+	var alphabet []byte
+	var found [256]bool
+	sample := make([]byte, 1<<16)
+	randomFill(rand.New(rand.NewSource(0)), sample)
+	for _, b := range sample {
+		if !found[b] {
+			found[b] = true
+			alphabet = append(alphabet, b)
+		}
+	}
+	sort.Slice(alphabet, func(i, j int) bool { return alphabet[i] < alphabet[j] })
+	return alphabet
+}
+
+// interpretTextEntropy returns randomFill, that generates characters with
+// Shannon entropy of --entropy.
+func interpretTextEntropy(params *tParameters, randomFill func(*rand.Rand, []byte), err error) (func(*rand.Rand, []byte), error) {
+	if err == nil && params.entropy.Available() && !params.binary.Available() {
+		alphabet := alphabetOf(randomFill)
+		entropy, err := strconv.ParseFloat(params.entropy.Values[0], 64)
+		entropyMax := math.Log2(float64(len(alphabet)))
+		if err != nil || entropy < 0 || entropy > entropyMax {
+			return nil, errors.New("can't parse entropy (expected 0 to " + strconv.FormatFloat(entropyMax, 'f', 2, 64) + " bits per character)")
+		}
+		table := newByteTable(entropyWeights(entropy, len(alphabet)))
+		for i, index := range table {
+			table[i] = alphabet[index]
+		}
+		return func(random *rand.Rand, bytes []byte) {
+			for i := range bytes {
+				bytes[i] = table[random.Int63()&0xffff]
+			}
+		}, nil
+	}
+	return randomFill, err
+}
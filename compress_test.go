@@ -0,0 +1,63 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"math"
+	"testing"
+)
+
+func TestCompressRatio(t *testing.T) {
+	newLine := []byte{'\n'}
+	for _, format := range []tFormat{new(tText), new(tBinary)} {
+		for _, ratio := range []float64{0.1, 0.4, 0.7} {
+			content := new(tContent)
+			content.randomFill = randomFillZ
+			content.seed = 1
+			content.format = format
+			compressible, err := newCompressible(content, ratio, newLine)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			output := generateTest(compressible, 1<<22, 1<<20, newLine)
+			var compressed bytes.Buffer
+			writer, _ := flate.NewWriter(&compressed, flate.BestCompression)
+			writer.Write(output)
+			writer.Close()
+			if actual := float64(compressed.Len()) / float64(len(output)); math.Abs(actual-ratio) > 0.03 {
+				t.Error("ratio", ratio, "is", actual)
+			}
+		}
+	}
+}
+
+func TestCompressRatioMax(t *testing.T) {
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	content.format = new(tText)
+	if _, err := newCompressible(content, 0.95, []byte{'\n'}); err == nil {
+		t.Error("no error for ratio above text")
+	}
+}
+
+func TestTextEntropy(t *testing.T) {
+	alphabet := alphabetOf(randomFillZ)
+	if len(alphabet) != 89 {
+		t.Error("wrong alphabet:", string(alphabet))
+	}
+	table := newByteTable(entropyWeights(4, len(alphabet)))
+	counts := make([]float64, len(alphabet))
+	for _, index := range table {
+		counts[index]++
+	}
+	if actual := shannonEntropy(counts); math.Abs(actual-4) > 0.01 {
+		t.Error("entropy 4 is", actual)
+	}
+}
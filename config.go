@@ -0,0 +1,337 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+)
+
+const (
+	configDEPTH_DEFAULT  = 3
+	configFANOUT_DEFAULT = 4
+)
+
+// tYAMLFormat generates a YAML document. Records are top-level keys
+// with scalars, block mappings, block sequences and literal blocks.
+// Records are stretched by the name of the key.
+type tYAMLFormat struct {
+	maxDepth int
+	fanout   int
+}
+
+// tTOMLFormat generates a TOML document. Records are tables with
+// sub-tables, arrays of tables, arrays and inline tables. Records are
+// stretched by a string value.
+type tTOMLFormat struct {
+	maxDepth int
+	fanout   int
+}
+
+// tINIFormat generates an INI file. Records are sections, nesting is
+// expressed by dotted section names. Records are stretched by a text
+// value.
+type tINIFormat struct {
+	maxDepth int
+	fanout   int
+}
+
+func interpretConfig(params *tParameters, kind string) (tFormat, error) {
+	var err error
+	var maxDepth, fanout int
+	maxDepth, err = interpretDepth(params, configDEPTH_DEFAULT, err)
+	fanout, err = interpretFanout(params, configFANOUT_DEFAULT, err)
+	if err == nil {
+		switch kind {
+		case "yaml":
+			return newRecords(&tYAMLFormat{maxDepth, fanout}), nil
+		case "toml":
+			return newRecords(&tTOMLFormat{maxDepth, fanout}), nil
+		}
+		return newRecords(&tINIFormat{maxDepth, fanout}), nil
+	}
+	return nil, err
+}
+
+// uniqueNames returns n different names.
+func (generator *tGenerator) uniqueNames(n int) []string {
+	names := make([]string, 0, n)
+	for len(names) < n {
+		name := string(generator.appendName(generator.scratch[:0], 3, 10))
+		if indexOf(names, name) < 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// appendTopName appends a name followed by position of record in
+// output, i.e. a name unique in whole output.
+func (generator *tGenerator) appendTopName(dst []byte) []byte {
+	dst = generator.appendName(dst, 3, 10)
+	dst = append(dst, '_')
+	return strconv.AppendInt(dst, int64(generator.position()), 10)
+}
+
+func appendIndent(dst []byte, indent int) []byte {
+	for i := 0; i < indent; i++ {
+		dst = append(dst, ' ')
+	}
+	return dst
+}
+
+func (format *tYAMLFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, "---"...)
+	return append(dst, newLine...)
+}
+
+func (format *tYAMLFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = generator.appendTopName(dst)
+	generator.markStretch(bytes.LastIndexByte(dst, '_'), randomFillL)
+	dst = append(dst, ':')
+	dst = format.appendValue(dst, generator, newLine, 1, 0)
+	return append(dst, newLine...)
+}
+
+func (format *tYAMLFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// appendValue appends a value following a key or dash.
+func (format *tYAMLFormat) appendValue(dst []byte, generator *tGenerator, newLine []byte, depth, indent int) []byte {
+	kinds := 9
+	if depth < format.maxDepth {
+		kinds = 12
+	}
+	switch kind := generator.random.Intn(kinds); kind {
+	case 9:
+		members := generator.random.Intn(format.fanout + 1)
+		if members == 0 {
+			return append(dst, " {}"...)
+		}
+		for _, name := range generator.uniqueNames(members) {
+			dst = append(dst, newLine...)
+			dst = appendIndent(dst, indent+2)
+			dst = append(dst, name...)
+			dst = append(dst, ':')
+			dst = format.appendValue(dst, generator, newLine, depth+1, indent+2)
+		}
+		return dst
+	case 10, 11:
+		elements := generator.random.Intn(format.fanout + 1)
+		if elements == 0 {
+			return append(dst, " []"...)
+		}
+		for i := 0; i < elements; i++ {
+			dst = append(dst, newLine...)
+			dst = appendIndent(dst, indent+2)
+			dst = append(dst, '-')
+			dst = format.appendValue(dst, generator, newLine, depth+1, indent+2)
+		}
+		return dst
+	case 8:
+		dst = append(dst, " |"...)
+		for i := generator.random.Intn(4); i >= 0; i-- {
+			dst = append(dst, newLine...)
+			dst = appendIndent(dst, indent+2)
+			dst = generator.appendWords(dst, 1+generator.random.Intn(8))
+		}
+		return dst
+	default:
+		dst = append(dst, ' ')
+		return format.appendScalar(dst, generator, kind)
+	}
+}
+
+func (format *tYAMLFormat) appendScalar(dst []byte, generator *tGenerator, kind int) []byte {
+	switch kind {
+	case 0, 1:
+		generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(6))
+		return appendJSONString(dst, generator.scratch)
+	case 2:
+		return generator.appendName(dst, 1, 12)
+	case 3:
+		return strconv.AppendInt(dst, generator.random.Int63n(2000001)-1000000, 10)
+	case 4:
+		return strconv.AppendFloat(dst, (generator.random.Float64()-0.5)*1e4, 'f', 1+generator.random.Intn(6), 64)
+	case 5:
+		return strconv.AppendBool(dst, generator.random.Intn(2) == 0)
+	case 6:
+		return append(dst, "null"...)
+	}
+	return randomTime(generator.random).AppendFormat(dst, dateLAYOUT)
+}
+
+func (format *tTOMLFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, "title = "...)
+	generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(6))
+	dst = appendJSONString(dst, generator.scratch)
+	dst = append(dst, newLine...)
+	return append(dst, newLine...)
+}
+
+func (format *tTOMLFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	path := generator.appendTopName(nil)
+	return format.appendTable(dst, generator, newLine, path, false, 1)
+}
+
+func (format *tTOMLFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// appendTable appends header and key/value pairs of a table. Sub-tables
+// follow the pairs.
+func (format *tTOMLFormat) appendTable(dst []byte, generator *tGenerator, newLine, path []byte, array bool, depth int) []byte {
+	var tables []string
+	if array {
+		dst = append(dst, "[["...)
+		dst = append(dst, path...)
+		dst = append(dst, "]]"...)
+	} else {
+		dst = append(dst, '[')
+		dst = append(dst, path...)
+		dst = append(dst, ']')
+	}
+	dst = append(dst, newLine...)
+	for _, name := range generator.uniqueNames(generator.random.Intn(format.fanout + 1)) {
+		if depth < format.maxDepth && generator.random.Intn(4) == 0 {
+			tables = append(tables, name)
+		} else {
+			dst = append(dst, name...)
+			dst = append(dst, " = "...)
+			dst = format.appendValue(dst, generator, depth)
+			dst = append(dst, newLine...)
+		}
+	}
+	dst = append(dst, newLine...)
+	for _, name := range tables {
+		pathSub := append(append(append([]byte{}, path...), '.'), name...)
+		if generator.random.Intn(3) == 0 {
+			for i := generator.random.Intn(format.fanout); i >= 0; i-- {
+				dst = format.appendTable(dst, generator, newLine, pathSub, true, depth+1)
+			}
+		} else {
+			dst = format.appendTable(dst, generator, newLine, pathSub, false, depth+1)
+		}
+	}
+	return dst
+}
+
+// appendValue appends a scalar, an array or an inline table.
+func (format *tTOMLFormat) appendValue(dst []byte, generator *tGenerator, depth int) []byte {
+	kinds := 7
+	if depth < format.maxDepth {
+		kinds = 9
+	}
+	switch generator.random.Intn(kinds) {
+	case 0, 1:
+		generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(6))
+		dst = appendJSONString(dst, generator.scratch)
+		generator.markStretch(len(dst)-1, generator.wordFill())
+		return dst
+	case 2:
+		return strconv.AppendInt(dst, generator.random.Int63n(2000001)-1000000, 10)
+	case 3:
+		return strconv.AppendFloat(dst, (generator.random.Float64()-0.5)*1e4, 'f', 1+generator.random.Intn(6), 64)
+	case 4:
+		return strconv.AppendBool(dst, generator.random.Intn(2) == 0)
+	case 5:
+		return randomTime(generator.random).AppendFormat(dst, time.RFC3339)
+	case 6:
+		return randomTime(generator.random).AppendFormat(dst, dateLAYOUT)
+	case 7:
+		dst = append(dst, '[')
+		for i := generator.random.Intn(format.fanout + 1); i > 0; i-- {
+			dst = format.appendValue(dst, generator, depth+1)
+			if i > 1 {
+				dst = append(dst, ", "...)
+			}
+		}
+		return append(dst, ']')
+	}
+	dst = append(dst, '{')
+	for i, name := range generator.uniqueNames(generator.random.Intn(format.fanout + 1)) {
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+		dst = append(dst, name...)
+		dst = append(dst, " = "...)
+		dst = format.appendValue(dst, generator, depth+1)
+	}
+	return append(dst, '}')
+}
+
+func (format *tINIFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func (format *tINIFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	path := generator.appendTopName(nil)
+	return format.appendSection(dst, generator, newLine, path, 1)
+}
+
+func (format *tINIFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// appendSection appends a section and its sub-sections.
+func (format *tINIFormat) appendSection(dst []byte, generator *tGenerator, newLine, path []byte, depth int) []byte {
+	dst = append(dst, '[')
+	dst = append(dst, path...)
+	dst = append(dst, ']')
+	dst = append(dst, newLine...)
+	if generator.random.Intn(4) == 0 {
+		dst = append(dst, "; "...)
+		dst = generator.appendWords(dst, 1+generator.random.Intn(8))
+		dst = append(dst, newLine...)
+	}
+	for _, name := range generator.uniqueNames(1 + generator.random.Intn(format.fanout)) {
+		dst = append(dst, name...)
+		dst = append(dst, " = "...)
+		dst = format.appendValue(dst, generator)
+		dst = append(dst, newLine...)
+	}
+	dst = append(dst, newLine...)
+	if depth < format.maxDepth {
+		for _, name := range generator.uniqueNames(generator.random.Intn(format.fanout/2 + 1)) {
+			pathSub := append(append(append([]byte{}, path...), '.'), name...)
+			dst = format.appendSection(dst, generator, newLine, pathSub, depth+1)
+		}
+	}
+	return dst
+}
+
+// appendValue appends text, a number, a boolean or a list separated by
+// commas. Values contain no percent signs, i.e. no interpolation.
+func (format *tINIFormat) appendValue(dst []byte, generator *tGenerator) []byte {
+	switch generator.random.Intn(6) {
+	case 0, 1:
+		for i := generator.random.Intn(6); i >= 0; i-- {
+			dst = generator.appendName(dst, 1, 12)
+			if i > 0 {
+				dst = append(dst, ' ')
+			}
+		}
+		generator.markStretch(len(dst), randomFillL)
+		return dst
+	case 2:
+		return strconv.AppendInt(dst, generator.random.Int63n(2000001)-1000000, 10)
+	case 3:
+		return strconv.AppendFloat(dst, generator.random.Float64()*1e4, 'f', 1+generator.random.Intn(6), 64)
+	case 4:
+		return append(dst, [...]string{"true", "false", "yes", "no", "on", "off"}[generator.random.Intn(6)]...)
+	}
+	for i := generator.random.Intn(format.fanout); i >= 0; i-- {
+		dst = generator.appendName(dst, 1, 12)
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,72 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUniqueNames(t *testing.T) {
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	generator := newGenerator(0, content)
+	names := generator.uniqueNames(500)
+	for i, name := range names {
+		if indexOf(names[:i], name) >= 0 {
+			t.Error("duplicate name:", name)
+		}
+	}
+}
+
+func TestINISections(t *testing.T) {
+	output := generateTest(newRecords(&tINIFormat{3, 4}), 50000, 2000, []byte{'\r', '\n'})
+	assertUniqueHeaders(t, output)
+}
+
+func TestTOMLTables(t *testing.T) {
+	output := generateTest(newRecords(&tTOMLFormat{4, 4}), 50000, 10000, []byte{'\n'})
+	if !bytes.HasPrefix(output, []byte("title = \"")) {
+		t.Error("title missing")
+	}
+	assertUniqueHeaders(t, output)
+}
+
+func TestYAMLKeys(t *testing.T) {
+	output := generateTest(newRecords(&tYAMLFormat{4, 4}), 50000, 2000, []byte{'\n'})
+	if !bytes.HasPrefix(output, []byte("---\n")) {
+		t.Error("document start missing")
+	}
+	var keys []string
+	for _, line := range bytes.Split(output, []byte{'\n'})[1:] {
+		if len(line) > 0 && line[0] != ' ' {
+			key := string(line[:bytes.IndexByte(line, ':')])
+			if indexOf(keys, key) >= 0 {
+				t.Error("duplicate key:", key)
+			}
+			keys = append(keys, key)
+		}
+	}
+}
+
+// assertUniqueHeaders checks headers of top-level sections and tables.
+func assertUniqueHeaders(t *testing.T, output []byte) {
+	var headers []string
+	for _, line := range bytes.Split(output, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte{'['}) && bytes.IndexByte(line, '.') < 0 {
+			if indexOf(headers, string(line)) >= 0 {
+				t.Error("duplicate header:", string(line))
+			}
+			headers = append(headers, string(line))
+		}
+	}
+	if len(headers) < 10 {
+		t.Error("too few headers:", len(headers))
+	}
+}
@@ -0,0 +1,363 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+)
+
+const (
+	defectTRUNCATED = "truncated record"
+	defectDELIMITER = "missing delimiter"
+	defectQUOTE     = "unbalanced quote"
+	defectCOLUMNS   = "wrong column count"
+	defectKEY       = "duplicate key"
+	defectESCAPE    = "bad escape"
+	defectINDENT    = "bad indentation"
+)
+
+const reportSUFFIX = ".defects"
+
+// tCorruptFormat is a record format, that can inject a defect into a
+// record. It returns the record, the index of the defect in record and
+// the kind of defect.
+type tCorruptFormat interface {
+	corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string)
+}
+
+// tDefect is a defect at offset in output.
+type tDefect struct {
+	offset int
+	kind   string
+}
+
+func interpretCorrupt(params *tParameters, format tFormat, err error) error {
+	if err == nil && params.corrupt.Available() {
+		records, ok := format.(*tRecords)
+		if ok {
+			_, ok = records.format.(tCorruptFormat)
+		}
+		if !ok {
+			return errors.New("corrupt is not supported by format (expected csv, tsv, json, jsonl, xml, html, sql or yaml)")
+		}
+		if !params.outputToFile() {
+			return errors.New("corrupt needs an output file, i.e. a path for the report")
+		}
+		// offsets in report are offsets of records in output
+		if params.encode.Available() || params.holes.Available() {
+			return errors.New("corrupt, encode and holes are exclusive")
+		}
+		if _, errStat := os.Stat(params.output.Values[0] + reportSUFFIX); errStat == nil {
+			return errors.New("report file exists already")
+		}
+		records.corrupt, err = interpretRate(params.corrupt, "corrupt", err)
+	}
+	return err
+}
+
+// createReport creates the file of defects next to output, if output is
+// corrupted. An existing file is not overwritten, since it isn't named by
+// the user.
+func createReport(params *tParameters) (*os.File, error) {
+	if params.corrupt.Available() {
+		return os.OpenFile(params.output.Values[0]+reportSUFFIX, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	}
+	return nil, nil
+}
+
+// writeReport writes a line with offset and kind for each defect in
+// buffer.
+func (generator *tGenerator) writeReport(report *os.File) error {
+	if report != nil && len(generator.defects) > 0 {
+		var lines []byte
+		for _, defect := range generator.defects {
+			lines = strconv.AppendInt(lines, int64(defect.offset), 10)
+			lines = append(lines, '\t')
+			lines = append(lines, defect.kind...)
+			lines = append(lines, '\n')
+		}
+		_, err := report.Write(lines)
+		return err
+	}
+	return nil
+}
+
+// corruptRecord injects a defect into record at the rate of records and
+// returns it with the defect's index in record. Index is negative, if
+// record is not corrupted.
+func (records *tRecords) corruptRecord(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	if records.corrupt > 0 && generator.random.Float64() < records.corrupt {
+		return records.format.(tCorruptFormat).corrupt(record, generator, newLine)
+	}
+	return record, -1, ""
+}
+
+// truncate cuts body at a random index and appends suffix.
+func truncate(body, suffix []byte, generator *tGenerator) ([]byte, int, string) {
+	index := 1
+	if len(body) > 2 {
+		index += generator.random.Intn(len(body) - 1)
+	}
+	if index > len(body) {
+		index = len(body)
+	}
+	suffix = append([]byte(nil), suffix...)
+	return append(body[:index], suffix...), index, defectTRUNCATED
+}
+
+func insertString(dst []byte, index int, str string) []byte {
+	dst = append(dst, str...)
+	copy(dst[index+len(str):], dst[index:len(dst)-len(str)])
+	copy(dst[index:], str)
+	return dst
+}
+
+func removeByte(dst []byte, index int) []byte {
+	return append(dst[:index], dst[index+1:]...)
+}
+
+// indicesUnquoted returns indices of b outside of quotes. Escaped bytes
+// in quotes are skipped, if escape is true.
+func indicesUnquoted(body []byte, b, quote byte, escape bool) []int {
+	var indices []int
+	var quoted bool
+	for i := 0; i < len(body); i++ {
+		if quoted {
+			if escape && body[i] == '\\' {
+				i++
+			} else if body[i] == quote {
+				quoted = false
+			}
+		} else if body[i] == quote {
+			quoted = true
+		} else if body[i] == b {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// indicesQuotes returns indices of opening and closing quotes of strings.
+func indicesQuotes(body []byte, quote byte, escape bool) ([]int, []int) {
+	var opening, closing []int
+	var quoted bool
+	for i := 0; i < len(body); i++ {
+		if quoted {
+			if escape && body[i] == '\\' {
+				i++
+			} else if body[i] == quote {
+				quoted = false
+				closing = append(closing, i)
+			}
+		} else if body[i] == quote {
+			quoted = true
+			opening = append(opening, i)
+		}
+	}
+	return opening, closing
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func chooseIndex(indices []int, generator *tGenerator) int {
+	return indices[generator.random.Intn(len(indices))]
+}
+
+// corrupt removes a delimiter, adds an unbalanced quote, truncates the
+// record before a delimiter or appends a field.
+func (format *tCSVFormat) corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	body := record[:len(record)-len(newLine)]
+	delimiters := indicesUnquoted(body, format.delimiter[0], '"', false)
+	switch generator.random.Intn(4) {
+	case 0:
+		// merging quoted fields might keep the number of columns
+		var unquoted []int
+		for _, delimiter := range delimiters {
+			if delimiter > 0 && body[delimiter-1] != '"' && delimiter+len(format.delimiter) < len(body) && body[delimiter+len(format.delimiter)] != '"' {
+				unquoted = append(unquoted, delimiter)
+			}
+		}
+		if len(unquoted) > 0 {
+			index := chooseIndex(unquoted, generator)
+			return append(body[:index], record[index+len(format.delimiter):]...), index, defectDELIMITER
+		}
+	case 1:
+		// quote must not be closed by a later quote
+		last := bytes.LastIndexByte(body, '"')
+		var starts []int
+		if last < 0 {
+			starts = append(starts, 0)
+		}
+		for _, delimiter := range delimiters {
+			if delimiter > last {
+				starts = append(starts, delimiter+len(format.delimiter))
+			}
+		}
+		if len(starts) > 0 {
+			index := chooseIndex(starts, generator)
+			return insertString(record, index, "\""), index, defectQUOTE
+		}
+		return removeByte(record, last), last, defectQUOTE
+	case 2:
+		if len(delimiters) > 0 {
+			index := chooseIndex(delimiters, generator)
+			return append(body[:index], newLine...), index, defectTRUNCATED
+		}
+	}
+	index := len(body)
+	record = insertString(record, index, string(format.delimiter))
+	return insertString(record, index+len(format.delimiter), "x"), index, defectCOLUMNS
+}
+
+// corrupt removes a comma or colon, removes a closing quote, truncates the
+// record, duplicates the first key or inserts an invalid escape sequence.
+func (format *tJSONFormat) corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	suffix := len(newLine)
+	if !format.lines {
+		suffix++
+	}
+	body := record[:len(record)-suffix]
+	opening, closing := indicesQuotes(body, '"', true)
+	switch generator.random.Intn(5) {
+	case 0:
+		delimiters := indicesUnquoted(body, ':', '"', true)
+		for _, comma := range indicesUnquoted(body, ',', '"', true) {
+			// numbers would merge to one number
+			if !isDigit(body[comma-1]) || !isDigit(body[comma+1]) {
+				delimiters = append(delimiters, comma)
+			}
+		}
+		if len(delimiters) > 0 {
+			index := chooseIndex(delimiters, generator)
+			return removeByte(record, index), index, defectDELIMITER
+		}
+	case 1:
+		if len(closing) > 0 {
+			index := chooseIndex(closing, generator)
+			return removeByte(record, index), index, defectQUOTE
+		}
+	case 2:
+		if len(opening) > 0 && opening[0] == 1 {
+			key := string(body[opening[0] : closing[0]+1])
+			return insertString(record, 1, key+":null,"), 1, defectKEY
+		}
+	case 3:
+		if len(opening) > 0 {
+			index := chooseIndex(opening, generator) + 1
+			return insertString(record, index, "\\x"), index, defectESCAPE
+		}
+	}
+	return truncate(body, record[len(body):], generator)
+}
+
+// corrupt removes '>' of the first tag, adds an unclosed or duplicate
+// attribute, truncates the record or inserts an invalid reference.
+func (format *tMarkupFormat) corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	body := record[:len(record)-len(newLine)]
+	end := bytes.IndexByte(body, '>')
+	if end > 0 {
+		attributes := end
+		if body[end-1] == '/' {
+			attributes--
+		}
+		switch generator.random.Intn(5) {
+		case 0:
+			return removeByte(record, end), end, defectDELIMITER
+		case 1:
+			return insertString(record, attributes, " q=\"x"), attributes, defectQUOTE
+		case 2:
+			return insertString(record, attributes, " d=\"1\" d=\"2\""), attributes, defectKEY
+		case 3:
+			return insertString(record, end+1, "&#xZZ;"), end + 1, defectESCAPE
+		}
+	}
+	return truncate(body, record[len(body):], generator)
+}
+
+// corrupt removes a comma between values, adds an unbalanced quote,
+// truncates the row or appends a value.
+func (format *tSQLFormat) corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	var start int
+	if bytes.HasPrefix(record, format.insert) {
+		start = len(format.insert) + len(newLine)
+	}
+	body := record[:len(record)-len(newLine)-1]
+	switch generator.random.Intn(4) {
+	case 0:
+		delimiters := indicesUnquoted(body[start:], ',', '\'', format.dialect == dialectMYSQL)
+		if len(delimiters) > 0 {
+			index := start + chooseIndex(delimiters, generator)
+			return removeByte(record, index), index, defectDELIMITER
+		}
+	case 1:
+		return insertString(record, start+1, "'"), start + 1, defectQUOTE
+	case 2:
+		truncated, index, kind := truncate(body[start:], record[len(body):], generator)
+		return append(record[:start], truncated...), start + index, kind
+	}
+	index := len(body) - 1
+	return insertString(record, index, ", NULL"), index, defectCOLUMNS
+}
+
+// corrupt duplicates the top-level key, adds a quote after a quoted
+// value, inserts an invalid escape sequence or a badly indented line.
+func (format *tYAMLFormat) corrupt(record []byte, generator *tGenerator, newLine []byte) ([]byte, int, string) {
+	key := record[:bytes.IndexByte(record, ':')]
+	begin, end := indicesYAMLQuoted(record)
+	switch generator.random.Intn(4) {
+	case 0:
+		index := len(record)
+		record = append(record, key...)
+		record = append(record, ": null"...)
+		return append(record, newLine...), index, defectKEY
+	case 1:
+		if end > 0 {
+			return insertString(record, end+1, "\""), end + 1, defectQUOTE
+		}
+	case 2:
+		if begin > 0 {
+			return insertString(record, begin+1, "\\q"), begin + 1, defectESCAPE
+		}
+	}
+	// line is less indented than a nested value, but not top-level
+	index := len(record)
+	record = append(record, ' ')
+	record = append(record, key...)
+	record = append(record, ": x"...)
+	return append(record, newLine...), index, defectINDENT
+}
+
+// indicesYAMLQuoted returns indices of quotes of the first double-quoted
+// value of a key, i.e. not in a literal block.
+func indicesYAMLQuoted(record []byte) (int, int) {
+	var offset int
+	for _, line := range bytes.SplitAfter(record, []byte{'\n'}) {
+		i := len(line) - len(bytes.TrimLeft(line, " "))
+		j := i
+		for j < len(line) && (line[j] >= 'a' && line[j] <= 'z' || line[j] == '_' || isDigit(line[j])) {
+			j++
+		}
+		if j > i && bytes.HasPrefix(line[j:], []byte(": \"")) {
+			begin := j + 2
+			for end := begin + 1; end < len(line); end++ {
+				if line[end] == '\\' {
+					end++
+				} else if line[end] == '"' {
+					return offset + begin, offset + end
+				}
+			}
+		}
+		offset += len(line)
+	}
+	return -1, -1
+}
@@ -0,0 +1,111 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/vbsw/golib/osargs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCorruptJSONLines(t *testing.T) {
+	format := &tJSONFormat{true, nil, 3, 4}
+	output, defects := generateCorruptTest(format, 1, 50000, 2000)
+	lines := bytes.Count(output, []byte{'\n'})
+	if len(defects) < lines-len(output)/2000-1 {
+		t.Error("too few defects:", len(defects), "of", lines)
+	}
+	for _, defect := range defects {
+		line := lineAt(output, defect.offset)
+		var value interface{}
+		if json.Unmarshal(line, &value) == nil && defect.kind != defectKEY {
+			t.Error("valid JSON with "+defect.kind+":", string(line))
+		}
+	}
+}
+
+func TestCorruptCSV(t *testing.T) {
+	format := &tCSVFormat{&tSchema{Columns: columnsCSVDefault()}, []byte{','}, false, make([]int, 5)}
+	for _, column := range format.schema.Columns {
+		column.init()
+	}
+	output, defects := generateCorruptTest(format, 0.1, 50000, 2000)
+	if len(defects) == 0 {
+		t.Fatal("no defects")
+	}
+	for _, defect := range defects {
+		line := lineAt(output, defect.offset)
+		fields, err := csv.NewReader(bytes.NewReader(line)).Read()
+		if err == nil && len(fields) == 5 {
+			t.Error("valid CSV with "+defect.kind+":", string(line))
+		}
+	}
+}
+
+func TestCorruptExclusive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "textgen")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "a.json")
+	records := newRecords(&tJSONFormat{true, nil, 3, 4})
+	for _, values := range [][]string{{"--encode=hex"}, {"--holes=0:1k"}} {
+		params := newCorruptTestParams(t, append([]string{"10k", output, "--corrupt=0.1"}, values...))
+		if interpretCorrupt(params, records, nil) == nil {
+			t.Error("corrupt with", values[0], "not recognized")
+		}
+	}
+	params := newCorruptTestParams(t, []string{"10k", output, "--corrupt=0.1"})
+	if interpretCorrupt(params, records, nil) != nil {
+		t.Error("corrupt rejected")
+	}
+	err = ioutil.WriteFile(output+reportSUFFIX, nil, 0666)
+	if err == nil && interpretCorrupt(params, records, nil) == nil {
+		t.Error("existing report not recognized")
+	}
+	if report, err := createReport(params); err == nil {
+		report.Close()
+		t.Error("existing report overwritten")
+	}
+}
+
+func newCorruptTestParams(t *testing.T, values []string) *tParameters {
+	args := new(osargs.Arguments)
+	args.Values = values
+	args.Parsed = make([]bool, len(args.Values))
+	params := new(tParameters)
+	err := params.initFromArgs(args)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return params
+}
+
+// generateCorruptTest returns output of format corrupted at rate and the
+// defects of all buffers.
+func generateCorruptTest(format tRecordFormat, rate float64, sizeFile, sizeBuffer int) ([]byte, []tDefect) {
+	var defects []tDefect
+	records := newRecords(format)
+	records.corrupt = rate
+	output := generateTestBuffers(records, sizeFile, sizeBuffer, []byte{'\n'}, func(generator *tGenerator) {
+		defects = append(defects, generator.defects...)
+	})
+	return output, defects
+}
+
+func lineAt(output []byte, offset int) []byte {
+	begin := bytes.LastIndexByte(output[:offset], '\n') + 1
+	end := offset + bytes.IndexByte(output[offset:], '\n')
+	return output[begin:end]
+}
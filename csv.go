@@ -0,0 +1,172 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+)
+
+const (
+	quoteMINIMAL = iota
+	quoteALWAYS
+	quoteNONNUMERIC
+	quoteNONE
+)
+
+type tCSVFormat struct {
+	schema    *tSchema
+	delimiter []byte
+	header    bool
+	quotes    []int
+}
+
+func interpretCSV(params *tParameters, delimiter string) (tFormat, error) {
+	var path string
+	if params.schema.Available() {
+		path = params.schema.Values[0]
+	}
+	schema, err := readSchema(path, columnsCSVDefault())
+	if err == nil {
+		format := new(tCSVFormat)
+		format.schema = schema
+		format.delimiter = []byte(delimiter)
+		format.header = schema.Header == nil || *schema.Header
+		if len(schema.Delimiter) > 0 {
+			format.delimiter = []byte(schema.Delimiter)
+		}
+		format.quotes = make([]int, len(schema.Columns))
+		for i, column := range schema.Columns {
+			quote := schema.Quote
+			if len(column.Quote) > 0 {
+				quote = column.Quote
+			}
+			format.quotes[i], err = parseQuoteStyle(quote)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+func columnsCSVDefault() []*tColumn {
+	columns := make([]*tColumn, 5)
+	columns[0] = newColumn("id", "int")
+	columns[1] = newColumn("name", "word")
+	columns[2] = newColumn("text", "words")
+	columns[3] = newColumn("value", "float")
+	columns[4] = newColumn("date", "date")
+	return columns
+}
+
+func parseQuoteStyle(quote string) (int, error) {
+	switch quote {
+	case "", "minimal":
+		return quoteMINIMAL, nil
+	case "always":
+		return quoteALWAYS, nil
+	case "nonnumeric":
+		return quoteNONNUMERIC, nil
+	case "none":
+		return quoteNONE, nil
+	}
+	return 0, errors.New("unknown quoting style \"" + quote + "\"")
+}
+
+// sequential returns true, if records are numbered by a column of type
+// seq.
+func (format *tCSVFormat) sequential() bool {
+	return format.schema.sequential()
+}
+
+func (format *tCSVFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.header {
+		for i, column := range format.schema.Columns {
+			if i > 0 {
+				dst = append(dst, format.delimiter...)
+			}
+			offset := len(dst)
+			dst = append(dst, column.Name...)
+			dst = format.quoteField(dst, offset, format.quotes[i], false)
+		}
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+func (format *tCSVFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	for i, column := range format.schema.Columns {
+		if i > 0 {
+			dst = append(dst, format.delimiter...)
+		}
+		if !column.isNull(generator) {
+			offset := len(dst)
+			dst = column.appendValue(dst, generator, newLine)
+			length := len(dst) - offset
+			stretch, fill := column.stretchOffset(dst[offset:], generator)
+			dst = format.quoteField(dst, offset, format.quotes[i], column.isNumeric())
+			if stretch >= 0 {
+				if len(dst)-offset > length && stretch == length {
+					// before closing quote, i.e. after doubled quotes
+					stretch = len(dst) - offset - 1
+				} else if len(dst)-offset > length {
+					stretch++
+				}
+				generator.markStretch(offset+stretch, fill)
+			}
+		}
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tCSVFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func (format *tCSVFormat) pad(dst []byte, generator *tGenerator, newLine []byte) {
+	// a line of spaces would be a record, so remainder is line feed
+	remainder := len(dst) % len(newLine)
+	for i := 0; i < remainder; i++ {
+		dst[i] = '\n'
+	}
+	fillBlank(dst[remainder:], newLine)
+}
+
+// quoteField quotes the field beginning at offset according to RFC 4180.
+func (format *tCSVFormat) quoteField(dst []byte, offset, quote int, numeric bool) []byte {
+	if quote != quoteNONE {
+		quotes := 0
+		special := quote == quoteALWAYS || quote == quoteNONNUMERIC && !numeric
+		for _, b := range dst[offset:] {
+			if b == '"' {
+				quotes++
+			} else if b == '\n' || b == '\r' || b == format.delimiter[0] {
+				special = true
+			}
+		}
+		if special || quotes > 0 {
+			lengthValue := len(dst) - offset
+			for i := 0; i <= quotes; i++ {
+				dst = append(dst, 0)
+			}
+			// move value and double quotes, from back to front
+			for i, j := offset+lengthValue-1, len(dst)-1; i >= offset; i-- {
+				dst[j] = dst[i]
+				j--
+				if dst[i] == '"' {
+					dst[j] = '"'
+					j--
+				}
+			}
+			dst[offset] = '"'
+			dst = append(dst, '"')
+		}
+	}
+	return dst
+}
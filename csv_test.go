@@ -0,0 +1,86 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestQuoteField(t *testing.T) {
+	format := new(tCSVFormat)
+	format.delimiter = []byte{','}
+	fields := []string{"abc", "a,b", "a\"b", "a\nb", "\"\""}
+	quoted := []string{"abc", "\"a,b\"", "\"a\"\"b\"", "\"a\nb\"", "\"\"\"\"\"\""}
+	for i, field := range fields {
+		result := string(format.quoteField([]byte("x,"+field), 2, quoteMINIMAL, false))
+		if result != "x,"+quoted[i] {
+			t.Error("wrong quoting:", result)
+		}
+	}
+	if string(format.quoteField([]byte("12"), 0, quoteNONNUMERIC, true)) != "12" {
+		t.Error("numeric field quoted")
+	}
+	if string(format.quoteField([]byte("ab"), 0, quoteALWAYS, false)) != "\"ab\"" {
+		t.Error("field not quoted")
+	}
+}
+
+func TestCSVRecords(t *testing.T) {
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	content.seed = 1
+	content.format = newRecords(newTestCSVFormat(t))
+	generator := newGenerator(4096, content)
+	generator.locate(0, len(generator.bytes))
+	generator.generate([]byte{'\r', '\n'})
+	reader := csv.NewReader(bytes.NewReader(generator.bytes))
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) < 2 || records[0][0] != "id" {
+		t.Error("header missing")
+	}
+}
+
+func TestCSVBuffers(t *testing.T) {
+	format := newRecords(newTestCSVFormat(t))
+	for _, sizeBuffer := range []int{1000, 1001, 1500} {
+		output := generateTest(format, 20000, sizeBuffer, []byte{'\r', '\n'})
+		if bytes.Contains(output, []byte("\n\r\n")) || !bytes.HasSuffix(output, []byte("\r\n")) {
+			t.Error("blank lines:", sizeBuffer)
+		}
+		reader := csv.NewReader(bytes.NewReader(output))
+		records, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, record := range records[1:] {
+			if record[0] == "id" {
+				t.Error("header repeated")
+			}
+		}
+	}
+}
+
+func newTestCSVFormat(t *testing.T) *tCSVFormat {
+	schema, err := readSchema("", columnsCSVDefault())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	schema.Columns[2].Quotes = 0.5
+	schema.Columns[2].NewLines = 0.5
+	format := new(tCSVFormat)
+	format.schema = schema
+	format.delimiter = []byte{','}
+	format.header = true
+	format.quotes = make([]int, len(schema.Columns))
+	return format
+}
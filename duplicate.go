@@ -0,0 +1,225 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	duplicateBLOCK_DEFAULT    = 4 * 1024
+	duplicateDISTANCE_DEFAULT = 100
+	duplicateLINE_MIN         = 16
+)
+
+// tDistance is the distribution of distances of repeats in lines or
+// blocks, either uniform from 1 to n or geometric with mean n.
+type tDistance struct {
+	geometric bool
+	n         int
+}
+
+// tDuplicated repeats earlier lines or blocks of output of format. A
+// repeated line or block is a copy of one at distance before it in the
+// same buffer, i.e. distances are limited by the buffer.
+type tDuplicated struct {
+	format    tFormat
+	lines     float64
+	blocks    float64
+	blockSize int
+	distance  tDistance
+}
+
+// tDuplicates counts lines and blocks, and those, that don't repeat
+// earlier ones.
+type tDuplicates struct {
+	lines        int
+	linesUnique  int
+	blocks       int
+	blocksUnique int
+}
+
+func interpretDuplicate(params *tParameters, format tFormat, err error) (tFormat, error) {
+	if err == nil && (params.dupLines.Available() || params.dupBlocks.Available()) {
+		duplicated := &tDuplicated{format: format, blockSize: duplicateBLOCK_DEFAULT}
+		_, text := format.(*tText)
+		_, binary := format.(*tBinary)
+		if params.compress.Available() {
+			return nil, errors.New("compress-ratio and duplicates are exclusive")
+		} else if params.dupLines.Available() && !text {
+			return nil, errors.New("dup-lines needs text output")
+		} else if params.dupBlocks.Available() && !text && !binary {
+			return nil, errors.New("dup-blocks needs text or binary output")
+		} else if params.dupLines.Available() && params.dupBlocks.Available() {
+			return nil, errors.New("dup-lines and dup-blocks are exclusive")
+		}
+		duplicated.lines, err = interpretRate(params.dupLines, "dup-lines", err)
+		duplicated.blocks, err = interpretRate(params.dupBlocks, "dup-blocks", err)
+		if err == nil && params.block.Available() {
+			duplicated.blockSize, err = parseBytes(params.block.Values[0])
+			if err != nil || duplicated.blockSize <= 0 {
+				return nil, errors.New("can't parse block size")
+			}
+		}
+		duplicated.distance, err = interpretDistance(params, err)
+		return duplicated, err
+	} else if err == nil && (params.block.Available() || params.distance.Available()) {
+		return nil, errors.New("block and distance need dup-lines or dup-blocks")
+	}
+	return format, err
+}
+
+// interpretDistance parses "uniform:N" or "geometric:N".
+func interpretDistance(params *tParameters, err error) (tDistance, error) {
+	distance := tDistance{true, duplicateDISTANCE_DEFAULT}
+	if err == nil && params.distance.Available() {
+		nameN := strings.SplitN(params.distance.Values[0], ":", 2)
+		switch strings.ToLower(nameN[0]) {
+		case "uniform":
+			distance.geometric = false
+		case "geometric":
+		default:
+			return distance, errors.New("unknown distance \"" + nameN[0] + "\" (expected uniform or geometric)")
+		}
+		if len(nameN) == 2 {
+			distance.n, err = strconv.Atoi(nameN[1])
+			if err != nil || distance.n <= 0 {
+				return distance, errors.New("can't parse distance (expected positive number)")
+			}
+		}
+	}
+	return distance, err
+}
+
+// sample returns a distance from 1 to max.
+func (distance *tDistance) sample(random *rand.Rand, max int) int {
+	d := 1
+	if distance.geometric {
+		if distance.n > 1 {
+			d += int(math.Log(1-random.Float64()) / math.Log(1-1/float64(distance.n)))
+		}
+	} else {
+		d += random.Intn(distance.n)
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+func (format *tDuplicated) alignment(newLine []byte) int {
+	if format.blocks > 0 {
+		return format.blockSize
+	}
+	return 1
+}
+
+func (format *tDuplicated) fill(generator *tGenerator, newLine []byte) {
+	format.format.fill(generator, newLine)
+	generator.duplicates = tDuplicates{}
+	if format.lines > 0 {
+		generator.duplicateLines(format.lines, &format.distance, newLine)
+	} else if format.blocks > 0 {
+		copies := generator.copyBlocks(format.blockSize, format.blocks, &format.distance)
+		generator.duplicates.blocks = (len(generator.bytes) + format.blockSize - 1) / format.blockSize
+		generator.duplicates.blocksUnique = generator.duplicates.blocks - copies
+	}
+}
+
+// duplicateLines rebuilds buffer from its lines and copies of previous
+// lines. The first line continues the last line of the previous buffer,
+// so it is not copied. A line, that doesn't fit, is replaced by a new
+// line, which is truncated and continued by the next buffer.
+func (generator *tGenerator) duplicateLines(rate float64, distance *tDistance, newLine []byte) {
+	var starts []int
+	source := append(generator.scratch[:0], generator.bytes...)
+	generator.scratch = source
+	written := 0
+	for written < len(generator.bytes) {
+		var line []byte
+		unique := true
+		if len(starts) > 0 && generator.random.Float64() < rate {
+			start := starts[len(starts)-distance.sample(generator.random, len(starts))]
+			end := start + bytes.Index(generator.bytes[start:], newLine) + len(newLine)
+			if end-start <= len(generator.bytes)-written {
+				line, unique = generator.bytes[start:end], false
+			}
+		}
+		if unique {
+			length := lineLength(source, 0, newLine)
+			// short lines of random words repeat by chance, so they are joined
+			for length < duplicateLINE_MIN && length < len(source) {
+				copy(source[length-len(newLine):], bytes.Repeat([]byte{' '}, len(newLine)))
+				length = lineLength(source, length, newLine)
+			}
+			line, source = source[:length], source[length:]
+		}
+		start := written
+		written += copy(generator.bytes[written:], line)
+		// a truncated line is neither counted nor copied
+		if bytes.HasSuffix(generator.bytes[:written], newLine) || written == generator.total-generator.offset {
+			generator.duplicates.lines++
+			if unique {
+				generator.duplicates.linesUnique++
+			}
+			if start > 0 || generator.first() {
+				starts = append(starts, start)
+			}
+		}
+	}
+}
+
+// lineLength returns length of line in bytes, that ends at the first new
+// line after offset, or at the end of bytes.
+func lineLength(text []byte, offset int, newLine []byte) int {
+	if index := bytes.Index(text[offset:], newLine); index >= 0 {
+		return offset + index + len(newLine)
+	}
+	return len(text)
+}
+
+// copyBlocks copies blocks of size at rate from blocks at distance before
+// them and returns the number of copies. The last block is not copied, if
+// it is incomplete.
+func (generator *tGenerator) copyBlocks(size int, rate float64, distance *tDistance) int {
+	var copies int
+	for offset := size; offset+size <= len(generator.bytes); offset += size {
+		if generator.random.Float64() < rate {
+			source := offset - size*distance.sample(generator.random, offset/size)
+			copy(generator.bytes[offset:offset+size], generator.bytes[source:])
+			copies++
+		}
+	}
+	return copies
+}
+
+func (duplicates *tDuplicates) add(other *tDuplicates) {
+	duplicates.lines += other.lines
+	duplicates.linesUnique += other.linesUnique
+	duplicates.blocks += other.blocks
+	duplicates.blocksUnique += other.blocksUnique
+}
+
+// printDuplicates prints the number of lines and blocks, and of unique
+// ones, if output has duplicates.
+func printDuplicates(params *tParameters, duplicates *tDuplicates) {
+	if params.dupLines.Available() {
+		fmt.Println("lines:", duplicates.lines)
+		fmt.Println("unique lines:", duplicates.linesUnique)
+	}
+	if params.dupBlocks.Available() {
+		fmt.Println("blocks:", duplicates.blocks)
+		fmt.Println("unique blocks:", duplicates.blocksUnique)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDuplicateLines(t *testing.T) {
+	for _, newLine := range [][]byte{{'\n'}, {'\r', '\n'}} {
+		format := &tDuplicated{format: new(tText), lines: 0.3, distance: tDistance{true, 10}}
+		var duplicates tDuplicates
+		output := generateTestBuffers(format, 1<<20+7, 1<<16, newLine, func(generator *tGenerator) {
+			duplicates.add(&generator.duplicates)
+		})
+		lines := bytes.Split(output, newLine)
+		unique := make(map[string]bool)
+		for _, line := range lines {
+			unique[string(line)] = true
+		}
+		if len(lines) != duplicates.lines || len(unique) != duplicates.linesUnique {
+			t.Error("lines", len(lines), len(unique), "reported", duplicates.lines, duplicates.linesUnique)
+		}
+		if rate := 1 - float64(len(unique))/float64(len(lines)); math.Abs(rate-0.3) > 0.02 {
+			t.Error("wrong rate:", rate)
+		}
+	}
+}
+
+func TestDuplicateBlocks(t *testing.T) {
+	format := &tDuplicated{format: new(tBinary), blocks: 0.5, blockSize: 512, distance: tDistance{false, 50}}
+	sizeBuffer := alignBuffer(format, 1<<16+100, []byte{'\n'})
+	var duplicates tDuplicates
+	output := generateTestBuffers(format, 1<<20+100, sizeBuffer, []byte{'\n'}, func(generator *tGenerator) {
+		duplicates.add(&generator.duplicates)
+	})
+	unique := make(map[string]bool)
+	blocks := 0
+	for offset := 0; offset < len(output); offset += 512 {
+		end := offset + 512
+		if end > len(output) {
+			end = len(output)
+		}
+		unique[string(output[offset:end])] = true
+		blocks++
+	}
+	if blocks != duplicates.blocks || len(unique) != duplicates.blocksUnique {
+		t.Error("blocks", blocks, len(unique), "reported", duplicates.blocks, duplicates.blocksUnique)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	distance := tDistance{true, 20}
+	sum := 0
+	for i := 0; i < 100000; i++ {
+		sum += distance.sample(random, 1000)
+	}
+	if mean := float64(sum) / 100000; math.Abs(mean-20) > 0.5 {
+		t.Error("mean of geometric distance is", mean)
+	}
+	distance = tDistance{false, 5}
+	for i := 0; i < 1000; i++ {
+		if d := distance.sample(random, 3); d < 1 || d > 3 {
+			t.Error("distance out of range:", d)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// tEncoding encodes groups of bytes to groups of characters.
+type tEncoding struct {
+	name         string
+	groupBytes   int
+	groupChars   int
+	widthDefault int
+	encode       func(dst, src []byte)
+}
+
+var encodings = []tEncoding{
+	{"base64", 3, 4, 76, base64.StdEncoding.Encode},
+	{"base32", 5, 8, 72, base32.StdEncoding.Encode},
+	{"hex", 1, 2, 64, func(dst, src []byte) { hex.Encode(dst, src) }},
+	{"ascii85", 4, 5, 75, encodeASCII85}}
+
+// tEncoded encodes output of format. Buffers are aligned to lines, so each
+// buffer encodes whole groups of bytes and is independent of others. The
+// remainder of the last line, that is too small for a group, is blank.
+type tEncoded struct {
+	encoding *tEncoding
+	width    int
+	content  *tContent
+}
+
+func interpretEncode(params *tParameters, content *tContent, err error) (tFormat, error) {
+	if err == nil && params.encode.Available() {
+		format := new(tEncoded)
+		for i := range encodings {
+			if encodings[i].name == strings.ToLower(params.encode.Values[0]) {
+				format.encoding = &encodings[i]
+			}
+		}
+		if format.encoding == nil {
+			return nil, errors.New("unknown encoding \"" + params.encode.Values[0] + "\" (expected base64, base32, hex or ascii85)")
+		}
+		format.width = format.encoding.widthDefault
+		if params.width.Available() {
+			format.width, err = strconv.Atoi(params.width.Values[0])
+			if err != nil || format.width < 0 || format.width%format.encoding.groupChars != 0 {
+				return nil, errors.New("can't parse width (expected multiple of " + strconv.Itoa(format.encoding.groupChars) + ")")
+			}
+		}
+		format.content = new(tContent)
+		*format.content = *content
+		return format, nil
+	} else if err == nil && params.width.Available() {
+		return nil, errors.New("width needs encode")
+	}
+	return content.format, err
+}
+
+// alignment returns length of lines, that encode a multiple of the
+// alignment of the encoded format.
+func (format *tEncoded) alignment(newLine []byte) int {
+	lineLength, lineBytes := format.encoding.groupChars, format.encoding.groupBytes
+	if format.width > 0 {
+		lineLength = format.width + len(newLine)
+		lineBytes = format.width / format.encoding.groupChars * format.encoding.groupBytes
+	}
+	if aligned, ok := format.content.format.(tAlignedFormat); ok {
+		alignment := aligned.alignment(newLine)
+		return lineLength * (alignment / gcd(alignment, lineBytes))
+	}
+	return lineLength
+}
+
+// rawSize returns the number of bytes encoded in size bytes of output.
+func (format *tEncoded) rawSize(size int, last bool, newLine []byte) int {
+	chars := size
+	if format.width > 0 {
+		lines := size / (format.width + len(newLine))
+		chars = lines * format.width
+		if rest := size - lines*(format.width+len(newLine)); rest > len(newLine) {
+			chars += rest - len(newLine)
+		}
+	} else if last {
+		chars -= len(newLine)
+	}
+	if chars > 0 {
+		return chars / format.encoding.groupChars * format.encoding.groupBytes
+	}
+	return 0
+}
+
+func (format *tEncoded) fill(generator *tGenerator, newLine []byte) {
+	offset := format.rawSize(generator.offset, false, newLine)
+	total := format.rawSize(generator.total, true, newLine)
+	size := format.rawSize(generator.offset+len(generator.bytes), generator.last(), newLine) - offset
+	if generator.inner == nil {
+		// kept for the next buffers of generator
+		generator.inner = newGenerator(size, format.content)
+	} else if cap(generator.inner.bytes) < size {
+		generator.inner.bytes = make([]byte, size)
+	}
+	inner := generator.inner
+	inner.bytes = inner.bytes[:size]
+	inner.locate(offset, total)
+	inner.records = generator.records
+	if size > 0 {
+		inner.generate(newLine)
+	}
+	generator.records = inner.records
+	generator.duplicates = inner.duplicates
+	generator.err = inner.err
+	encoded := make([]byte, size/format.encoding.groupBytes*format.encoding.groupChars)
+	format.encoding.encode(encoded, inner.bytes)
+	written := 0
+	for len(encoded) > 0 {
+		line := encoded
+		if format.width > 0 && len(line) > format.width {
+			line = line[:format.width]
+		}
+		written += copy(generator.bytes[written:], line)
+		encoded = encoded[len(line):]
+		if format.width > 0 && written+len(newLine) <= len(generator.bytes) {
+			written += copy(generator.bytes[written:], newLine)
+		}
+	}
+	fillBlank(generator.bytes[written:], newLine)
+	generator.written = len(generator.bytes)
+}
+
+// encodeASCII85 encodes groups of 4 bytes to 5 characters. Unlike package
+// ascii85, zero groups are not abbreviated, i.e. length is fixed.
+func encodeASCII85(dst, src []byte) {
+	for len(src) >= 4 {
+		value := uint32(src[0])<<24 | uint32(src[1])<<16 | uint32(src[2])<<8 | uint32(src[3])
+		for i := 4; i >= 0; i-- {
+			dst[i] = byte('!' + value%85)
+			value /= 85
+		}
+		src, dst = src[4:], dst[5:]
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
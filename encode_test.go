@@ -0,0 +1,59 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncodeASCII85(t *testing.T) {
+	src := []byte("textgen!\xff\x00\x01\x02")
+	expected := make([]byte, ascii85.MaxEncodedLen(len(src)))
+	expected = expected[:ascii85.Encode(expected, src)]
+	actual := make([]byte, len(src)/4*5)
+	encodeASCII85(actual, src)
+	if string(actual) != string(expected) {
+		t.Error(string(actual), "!=", string(expected))
+	}
+}
+
+func TestEncodeBase64(t *testing.T) {
+	for _, width := range []int{0, 76, 8} {
+		content := new(tContent)
+		content.randomFill = randomFillZ
+		content.format = new(tText)
+		format := &tEncoded{&encodings[0], width, content}
+		newLine := []byte{'\r', '\n'}
+		sizeBuffer := alignBuffer(format, 1000, newLine)
+		var inner *tGenerator
+		output := generateTestBuffers(format, 10007, sizeBuffer, newLine, func(generator *tGenerator) {
+			if inner != nil && generator.inner != inner {
+				t.Error("inner generator not kept")
+			}
+			inner = generator.inner
+		})
+		if len(output) != 10007 {
+			t.Error("wrong size:", len(output))
+		}
+		lines := bytes.Fields(output)
+		for _, line := range lines[:len(lines)-1] {
+			if width > 0 && len(line) != width {
+				t.Error("wrong width:", len(line))
+			}
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(lines, nil)))
+		if err != nil {
+			t.Error(err.Error())
+		} else if len(decoded) != format.rawSize(len(output), true, newLine) {
+			t.Error("wrong decoded size:", len(decoded))
+		}
+	}
+}
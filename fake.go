@@ -0,0 +1,269 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// fakes generate fake personal data. They are available as types of
+// columns and as functions in templates.
+var fakes = map[string]func(dst []byte, locale *tLocale, generator *tGenerator) []byte{
+	"name":       appendFakeName,
+	"firstname":  appendFakeFirstName,
+	"lastname":   appendFakeLastName,
+	"email":      appendFakeEmail,
+	"address":    appendFakeAddress,
+	"city":       appendFakeCity,
+	"phone":      appendFakePhone,
+	"iban":       appendFakeIBAN,
+	"creditcard": appendFakeCreditCard,
+	"ipv4":       appendFakeIPv4,
+	"ipv6":       appendFakeIPv6,
+	"url":        appendFakeURL,
+}
+
+// fakeLengths are the maximum lengths of fake data, e.g. for SQL types.
+var fakeLengths = map[string]float64{
+	"name":       64,
+	"firstname":  32,
+	"lastname":   32,
+	"email":      96,
+	"address":    128,
+	"city":       48,
+	"phone":      24,
+	"iban":       34,
+	"creditcard": 19,
+	"ipv4":       15,
+	"ipv6":       39,
+	"url":        128,
+}
+
+var cardPrefixes = []string{"4", "4", "51", "52", "53", "54", "55", "2221", "34", "37", "6011"}
+var urlTLDs = []string{"com", "org", "net", "io"}
+
+func init() {
+	for name, fake := range fakes {
+		fieldTypes[name] = &tFieldType{fieldSTRING, 0, fakeLengths[name], newFieldFake(fake)}
+	}
+}
+
+func newFieldFake(fake func([]byte, *tLocale, *tGenerator) []byte) func([]byte, *tColumn, *tGenerator) []byte {
+	return func(dst []byte, column *tColumn, generator *tGenerator) []byte {
+		if column.locale != nil {
+			return fake(dst, column.locale, generator)
+		}
+		return fake(dst, generator.locale(), generator)
+	}
+}
+
+// locale returns locale of content, or default locale.
+func (generator *tGenerator) locale() *tLocale {
+	if generator.content.locale != nil {
+		return generator.content.locale
+	}
+	return locales[localeDEFAULT]
+}
+
+func appendFakeName(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	dst = appendFakeFirstName(dst, locale, generator)
+	dst = append(dst, ' ')
+	return appendFakeLastName(dst, locale, generator)
+}
+
+func appendFakeFirstName(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	return append(dst, locale.firstNames[generator.random.Intn(len(locale.firstNames))]...)
+}
+
+func appendFakeLastName(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	return append(dst, locale.lastNames[generator.random.Intn(len(locale.lastNames))]...)
+}
+
+func appendFakeEmail(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	first := locale.firstNames[generator.random.Intn(len(locale.firstNames))]
+	last := locale.lastNames[generator.random.Intn(len(locale.lastNames))]
+	switch generator.random.Intn(4) {
+	case 0:
+		dst = append(dst, toASCII(first)...)
+		dst = append(dst, '.')
+		dst = append(dst, toASCII(last)...)
+	case 1:
+		dst = append(dst, toASCII(first)[0])
+		dst = append(dst, toASCII(last)...)
+	case 2:
+		dst = append(dst, toASCII(first)...)
+		dst = strconv.AppendInt(dst, generator.random.Int63n(100), 10)
+	default:
+		dst = append(dst, toASCII(last)...)
+		dst = append(dst, '_')
+		dst = append(dst, toASCII(first)...)
+	}
+	dst = append(dst, '@')
+	return append(dst, locale.domain...)
+}
+
+func appendFakeAddress(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	format := locale.address
+	for len(format) > 0 {
+		begin := strings.IndexByte(format, '{')
+		if begin < 0 {
+			return append(dst, format...)
+		}
+		end := begin + strings.IndexByte(format[begin:], '}')
+		dst = append(dst, format[:begin]...)
+		switch format[begin+1 : end] {
+		case "number":
+			dst = strconv.AppendInt(dst, 1+generator.random.Int63n(200), 10)
+		case "street":
+			dst = append(dst, locale.streets[generator.random.Intn(len(locale.streets))]...)
+		case "city":
+			dst = appendFakeCity(dst, locale, generator)
+		case "postcode":
+			dst = appendPattern(dst, locale.postcode, generator)
+		}
+		format = format[end+1:]
+	}
+	return dst
+}
+
+func appendFakeCity(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	return append(dst, locale.cities[generator.random.Intn(len(locale.cities))]...)
+}
+
+func appendFakePhone(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	return appendPattern(dst, locale.phone, generator)
+}
+
+// appendFakeIBAN appends an IBAN with valid check digits.
+func appendFakeIBAN(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	offset := len(dst)
+	dst = append(dst, locale.iban[:2]...)
+	dst = append(dst, '0', '0')
+	dst = appendPattern(dst, locale.iban[2:], generator)
+	check := 98 - ibanRemainder(dst[offset:])
+	dst[offset+2] = byte('0' + check/10)
+	dst[offset+3] = byte('0' + check%10)
+	return dst
+}
+
+// ibanRemainder returns the IBAN modulo 97, with country code and check
+// digits moved to the end.
+func ibanRemainder(iban []byte) int {
+	var remainder int
+	for i := range iban {
+		b := iban[(i+4)%len(iban)]
+		if b >= 'A' && b <= 'Z' {
+			remainder = (remainder*100 + int(b-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(b-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+// appendFakeCreditCard appends a card number with valid Luhn checksum.
+func appendFakeCreditCard(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	prefix := cardPrefixes[generator.random.Intn(len(cardPrefixes))]
+	length := 16
+	if prefix == "34" || prefix == "37" {
+		length = 15
+	}
+	offset := len(dst)
+	dst = append(dst, prefix...)
+	for i := len(prefix); i < length-1; i++ {
+		dst = append(dst, byte('0'+generator.random.Intn(10)))
+	}
+	dst = append(dst, '0')
+	dst[len(dst)-1] = byte('0' + (10-luhnSum(dst[offset:]))%10)
+	return dst
+}
+
+// luhnSum returns the sum of digits of Luhn algorithm modulo 10.
+func luhnSum(digits []byte) int {
+	var sum int
+	for i := 0; i < len(digits); i++ {
+		digit := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum % 10
+}
+
+func appendFakeIPv4(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	for i := 0; i < 4; i++ {
+		if i > 0 {
+			dst = append(dst, '.')
+		}
+		if i == 0 {
+			dst = strconv.AppendInt(dst, 1+generator.random.Int63n(223), 10)
+		} else {
+			dst = strconv.AppendInt(dst, generator.random.Int63n(256), 10)
+		}
+	}
+	return dst
+}
+
+// appendFakeIPv6 appends a global unicast address. Some groups are zero,
+// so addresses are compressed.
+func appendFakeIPv6(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	ip := make(net.IP, net.IPv6len)
+	generator.random.Read(ip)
+	ip[0] = 0x20 | ip[0]&0x1f
+	if generator.random.Intn(2) == 0 {
+		zeros := 2 * (1 + generator.random.Intn(5))
+		begin := 4 + 2*generator.random.Intn((net.IPv6len-4-zeros)/2+1)
+		for i := begin; i < begin+zeros; i++ {
+			ip[i] = 0
+		}
+	}
+	return append(dst, ip.String()...)
+}
+
+func appendFakeURL(dst []byte, locale *tLocale, generator *tGenerator) []byte {
+	dst = append(dst, "https://www."...)
+	dst = append(dst, toASCII(locale.lastNames[generator.random.Intn(len(locale.lastNames))])...)
+	dst = append(dst, '.')
+	dst = append(dst, urlTLDs[generator.random.Intn(len(urlTLDs))]...)
+	for i := generator.random.Intn(4); i > 0; i-- {
+		dst = append(dst, '/')
+		dst = generator.appendName(dst, 2, 10)
+	}
+	if generator.random.Intn(3) == 0 {
+		dst = append(dst, "?id="...)
+		dst = strconv.AppendInt(dst, generator.random.Int63n(100000), 10)
+	}
+	return dst
+}
+
+// appendPattern appends pattern with '#' replaced by digits and 'A' by
+// upper case letters.
+func appendPattern(dst []byte, pattern string, generator *tGenerator) []byte {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '#':
+			dst = append(dst, byte('0'+generator.random.Intn(10)))
+		case 'A':
+			dst = append(dst, byte('A'+generator.random.Intn(26)))
+		default:
+			dst = append(dst, pattern[i])
+		}
+	}
+	return dst
+}
+
+// toASCII returns name in lower case ASCII letters.
+func toASCII(name string) string {
+	return asciiReplacements.Replace(strings.ToLower(name))
+}
@@ -0,0 +1,84 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"regexp"
+	"testing"
+	"text/template"
+)
+
+func TestLuhn(t *testing.T) {
+	if luhnSum([]byte("4111111111111111")) != 0 || luhnSum([]byte("378282246310005")) != 0 || luhnSum([]byte("4111111111111112")) == 0 {
+		t.Error("wrong Luhn checksum")
+	}
+}
+
+func TestIBAN(t *testing.T) {
+	if ibanRemainder([]byte("DE89370400440532013000")) != 1 || ibanRemainder([]byte("GB82WEST12345698765432")) != 1 {
+		t.Error("wrong IBAN remainder")
+	}
+}
+
+func TestFakes(t *testing.T) {
+	email := regexp.MustCompile("^[a-z0-9._-]+@example\\.[a-z]+$")
+	generator := newGenerator(0, new(tContent))
+	for name, locale := range locales {
+		for i := 0; i < 100; i++ {
+			card := appendFakeCreditCard(nil, locale, generator)
+			if luhnSum(card) != 0 {
+				t.Error("wrong card number:", string(card))
+			}
+			iban := appendFakeIBAN(nil, locale, generator)
+			if ibanRemainder(iban) != 1 || string(iban[:2]) != locale.iban[:2] {
+				t.Error("wrong IBAN:", string(iban))
+			}
+			if value := appendFakeEmail(nil, locale, generator); !email.Match(value) {
+				t.Error("wrong email of locale", name+":", string(value))
+			}
+			if value := appendFakeIPv6(nil, locale, generator); net.ParseIP(string(value)) == nil {
+				t.Error("wrong IPv6:", string(value))
+			}
+			if value := appendFakeIPv4(nil, locale, generator); net.ParseIP(string(value)).To4() == nil {
+				t.Error("wrong IPv4:", string(value))
+			}
+		}
+	}
+}
+
+func TestFakeColumns(t *testing.T) {
+	columns := []*tColumn{newColumn("", "name"), newColumn("", "email"), newColumn("", "uuid"), newColumn("", "date"), newColumn("", "bool"), newColumn("", "pick")}
+	columns[5].Values = []string{"a", "b"}
+	schema, err := readSchema("", columns)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	formatCSV := &tCSVFormat{schema: schema, delimiter: []byte{','}, quotes: make([]int, len(columns))}
+	formatJSON := &tJSONFormat{schema: schema}
+	for _, sizeBuffer := range []int{1000, 1001} {
+		output := generateTest(newRecords(formatCSV), 20000, sizeBuffer, []byte{'\r', '\n'})
+		records, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+		if len(output) != 20000 || err != nil || len(records) < 100 {
+			t.Error("wrong CSV:", sizeBuffer, len(output), err)
+		}
+		output = generateTest(newRecords(formatJSON), 20000, sizeBuffer, []byte{'\n'})
+		if len(output) != 20000 || !json.Valid(output) {
+			t.Error("wrong JSON:", sizeBuffer)
+		}
+	}
+	format := new(tTemplateFormat)
+	format.template = template.Must(template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{name}} <{{email}}>\n"))
+	output := generateTest(newRecords(format), 20000, 1000, []byte{'\n'})
+	if len(output) != 20000 || bytes.Count(output, []byte{'>'}) < 100 {
+		t.Error("wrong template output:", string(output))
+	}
+}
@@ -0,0 +1,271 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+)
+
+const (
+	fieldSTRING = iota
+	fieldNUMBER
+	fieldBOOL
+)
+
+// tSchema describes the records of structured formats. It is read from
+// a JSON file.
+type tSchema struct {
+	Table     string     `json:"table"`
+	Delimiter string     `json:"delimiter"`
+	Header    *bool      `json:"header"`
+	Quote     string     `json:"quote"`
+	Columns   []*tColumn `json:"columns"`
+	Length    int        `json:"length"`
+	NewLine   *bool      `json:"newline"`
+	Pad       string     `json:"pad"`
+}
+
+// tColumn describes a field. Min and max are the range of numbers or
+// the range of length of strings.
+type tColumn struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Min          *float64 `json:"min"`
+	Max          *float64 `json:"max"`
+	Precision    *int     `json:"precision"`
+	Null         float64  `json:"null"`
+	Quote        string   `json:"quote"`
+	Quotes       float64  `json:"quotes"`
+	NewLines     float64  `json:"newlines"`
+	Charset      string   `json:"charset"`
+	Layout       string   `json:"layout"`
+	Values       []string `json:"values"`
+	SQL          string   `json:"sql"`
+	Locale       string   `json:"locale"`
+	Notation     string   `json:"notation"`
+	Distribution string   `json:"distribution"`
+	Decimal      string   `json:"decimal"`
+	Offset       *int     `json:"offset"`
+	Width        int      `json:"width"`
+	Pad          string   `json:"pad"`
+	Align        string   `json:"align"`
+	fieldType    *tFieldType
+	locale       *tLocale
+	min          float64
+	max          float64
+	minInt       int64
+	maxInt       int64
+}
+
+type tFieldType struct {
+	kind     int
+	min      float64
+	max      float64
+	generate func(dst []byte, column *tColumn, generator *tGenerator) []byte
+}
+
+var fieldTypes = map[string]*tFieldType{
+	"string": {fieldSTRING, 1, 20, appendFieldString},
+	"word":   {fieldSTRING, 0, 0, appendFieldWord},
+	"words":  {fieldSTRING, 1, 10, appendFieldWords},
+	"int":    {fieldNUMBER, 0, 1000000, appendFieldInt},
+	"float":  {fieldNUMBER, 0, 1000, appendFieldFloat},
+	"bool":   {fieldBOOL, 0, 0, appendFieldBool},
+	"date":   {fieldSTRING, 0, 0, appendFieldDate},
+	"uuid":   {fieldSTRING, 0, 0, appendFieldUUID},
+	"pick":   {fieldSTRING, 0, 0, appendFieldPick},
+	"seq":    {fieldNUMBER, 0, 1e18, appendFieldSeq},
+}
+
+// sequential returns true, if a column is of type seq, i.e. records are
+// numbered.
+func (schema *tSchema) sequential() bool {
+	for _, column := range schema.Columns {
+		if column.Type == "seq" {
+			return true
+		}
+	}
+	return false
+}
+
+// readSchema reads schema from file. If path is empty, columns are
+// set to columnsDefault.
+func readSchema(path string, columnsDefault []*tColumn) (*tSchema, error) {
+	schema := new(tSchema)
+	if len(path) > 0 {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("can't read schema file")
+		}
+		err = json.Unmarshal(text, schema)
+		if err != nil {
+			return nil, errors.New("can't parse schema: " + err.Error())
+		}
+		if len(schema.Columns) == 0 {
+			return nil, errors.New("schema has no columns")
+		}
+	} else {
+		schema.Columns = columnsDefault
+	}
+	for i, column := range schema.Columns {
+		err := column.init()
+		if err != nil {
+			return nil, errors.New("schema, column " + strconv.Itoa(i+1) + ": " + err.Error())
+		}
+	}
+	return schema, nil
+}
+
+func newColumn(name, fieldType string) *tColumn {
+	column := new(tColumn)
+	column.Name = name
+	column.Type = fieldType
+	return column
+}
+
+func (column *tColumn) init() error {
+	column.fieldType = fieldTypes[column.Type]
+	if column.fieldType == nil {
+		return errors.New("unknown type \"" + column.Type + "\"")
+	}
+	if len(column.Name) == 0 {
+		column.Name = column.Type
+	}
+	column.min, column.max = column.fieldType.min, column.fieldType.max
+	if column.Min != nil {
+		column.min = *column.Min
+	}
+	if column.Max != nil {
+		column.max = *column.Max
+	}
+	if !(column.min <= column.max) {
+		return errors.New("min is greater than max")
+	}
+	if column.Type == "pick" && len(column.Values) == 0 {
+		return errors.New("no values to pick")
+	}
+	if column.fieldType.kind == fieldNUMBER {
+		err := column.initNumber()
+		if err != nil {
+			return err
+		}
+	}
+	if len(column.Locale) > 0 {
+		var err error
+		column.locale, err = findLocale(column.Locale)
+		return err
+	}
+	return nil
+}
+
+// isNull returns true, if next value should be null.
+func (column *tColumn) isNull(generator *tGenerator) bool {
+	return column.Null > 0 && generator.random.Float64() < column.Null
+}
+
+// appendValue appends a random value. Strings may contain quotes and new
+// lines, if requested.
+func (column *tColumn) appendValue(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	lengthDst := len(dst)
+	dst = column.fieldType.generate(dst, column, generator)
+	if column.Quotes > 0 && generator.random.Float64() < column.Quotes {
+		dst = insertBytes(dst, lengthDst, generator.random, []byte{'"'})
+	}
+	if column.NewLines > 0 && generator.random.Float64() < column.NewLines {
+		dst = insertBytes(dst, lengthDst, generator.random, newLine)
+	}
+	return dst
+}
+
+// isNumeric returns true, if values are numbers or booleans in notation
+// of programming languages, i.e. JSON.
+func (column *tColumn) isNumeric() bool {
+	if column.fieldType.kind == fieldNUMBER {
+		return (column.Notation == "" || column.Notation == "decimal" || column.Notation == "scientific") && (column.Decimal == "" || column.Decimal == ".")
+	}
+	return column.fieldType.kind == fieldBOOL
+}
+
+// stretchOffset returns the offset in value, where bytes of the returned
+// fill can be inserted without changing the kind of value, or -1. Text is
+// stretched by letters, numbers aren't stretched.
+func (column *tColumn) stretchOffset(value []byte, generator *tGenerator) (int, func(*rand.Rand, []byte)) {
+	switch column.Type {
+	case "word", "words":
+		return len(value), generator.wordFill()
+	case "string":
+		if len(column.Charset) == 0 {
+			return len(value), generator.wordFill()
+		}
+	}
+	return -1, nil
+}
+
+// randIntRange returns a random integer in [min, max].
+func (column *tColumn) randIntRange(generator *tGenerator) int {
+	return int(column.min) + int(randUint64(generator.random, uint64(int64(column.max))-uint64(int64(column.min))))
+}
+
+func appendFieldString(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	randomFill := generator.randomFill
+	if len(column.Charset) > 0 {
+		randomFill = charsetFill(column.Charset)
+	}
+	return generator.appendRandom(dst, column.randIntRange(generator), randomFill)
+}
+
+func appendFieldWord(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return generator.appendWord(dst)
+}
+
+func appendFieldWords(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return generator.appendWords(dst, column.randIntRange(generator))
+}
+
+func appendFieldInt(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return column.appendInteger(dst, column.randInt(generator))
+}
+
+func appendFieldFloat(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return column.appendFloat(dst, column.randFloat(generator))
+}
+
+func appendFieldBool(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return strconv.AppendBool(dst, generator.random.Intn(2) == 0)
+}
+
+func appendFieldDate(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	if len(column.Layout) > 0 {
+		return randomTime(generator.random).AppendFormat(dst, column.Layout)
+	}
+	return randomTime(generator.random).AppendFormat(dst, dateLAYOUT)
+}
+
+func appendFieldUUID(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return appendUUID(dst, generator.random)
+}
+
+func appendFieldPick(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return append(dst, column.Values[generator.random.Intn(len(column.Values))]...)
+}
+
+// insertBytes inserts bytes at random position after offset.
+func insertBytes(dst []byte, offset int, random *rand.Rand, bytes []byte) []byte {
+	return insertAt(dst, offset+random.Intn(len(dst)-offset+1), bytes)
+}
+
+func insertAt(dst []byte, position int, bytes []byte) []byte {
+	dst = append(dst, bytes...)
+	copy(dst[position+len(bytes):], dst[position:])
+	copy(dst[position:], bytes)
+	return dst
+}
@@ -0,0 +1,160 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// tFixedFormat generates records of fixed width. Buffers are aligned to
+// records, so records are never split.
+type tFixedFormat struct {
+	schema  *tSchema
+	length  int
+	newLine bool
+	pad     byte
+	pads    []byte
+}
+
+func interpretFixed(params *tParameters) (tFormat, error) {
+	var path string
+	if params.schema.Available() {
+		path = params.schema.Values[0]
+	}
+	schema, err := readSchema(path, columnsFixedDefault())
+	if err == nil {
+		format := new(tFixedFormat)
+		format.schema = schema
+		format.newLine = schema.NewLine == nil || *schema.NewLine
+		format.pad, err = parsePad(schema.Pad, ' ')
+		if err == nil {
+			err = format.initLayout()
+			if err == nil {
+				return format, nil
+			}
+		}
+		return nil, errors.New("layout: " + err.Error())
+	}
+	return nil, err
+}
+
+func columnsFixedDefault() []*tColumn {
+	columns := columnsCSVDefault()
+	widths := []int{8, 16, 40, 10, 10}
+	for i, column := range columns {
+		column.Width = widths[i]
+	}
+	columns[0].Type = "seq"
+	columns[0].Align = "right"
+	columns[0].Pad = "0"
+	columns[2].Max = new(float64)
+	*columns[2].Max = 4
+	columns[3].Align = "right"
+	return columns
+}
+
+func parsePad(pad string, padDefault byte) (byte, error) {
+	if len(pad) == 0 {
+		return padDefault, nil
+	} else if len(pad) == 1 {
+		return pad[0], nil
+	}
+	return 0, errors.New("padding must be one character")
+}
+
+// initLayout sets offsets of fields, that have none, and checks fields
+// don't overlap.
+func (format *tFixedFormat) initLayout() error {
+	var offset int
+	for i, column := range format.schema.Columns {
+		if column.Width <= 0 {
+			return errors.New("field " + strconv.Itoa(i+1) + " has no width")
+		}
+		if column.Align != "" && column.Align != "left" && column.Align != "right" {
+			return errors.New("unknown alignment \"" + column.Align + "\" (expected left or right)")
+		}
+		if column.Offset == nil {
+			column.Offset = new(int)
+			*column.Offset = offset
+		}
+		offset = *column.Offset + column.Width
+		if offset > format.length {
+			format.length = offset
+		}
+		pad, err := parsePad(column.Pad, ' ')
+		if err != nil {
+			return err
+		}
+		format.pads = append(format.pads, pad)
+	}
+	if format.schema.Length > 0 {
+		if format.schema.Length < format.length {
+			return errors.New("fields exceed record length")
+		}
+		format.length = format.schema.Length
+	}
+	columns := make([]*tColumn, len(format.schema.Columns))
+	copy(columns, format.schema.Columns)
+	sort.Slice(columns, func(i, j int) bool { return *columns[i].Offset < *columns[j].Offset })
+	for i := 1; i < len(columns); i++ {
+		if *columns[i-1].Offset+columns[i-1].Width > *columns[i].Offset {
+			return errors.New("fields \"" + columns[i-1].Name + "\" and \"" + columns[i].Name + "\" overlap")
+		}
+	}
+	return nil
+}
+
+// alignment returns length of record.
+func (format *tFixedFormat) alignment(newLine []byte) int {
+	if format.newLine {
+		return format.length + len(newLine)
+	}
+	return format.length
+}
+
+// fill writes records. Records have fixed length, so the number of record
+// is computed from position, regardless of threads.
+func (format *tFixedFormat) fill(generator *tGenerator, newLine []byte) {
+	for generator.written < len(generator.bytes) {
+		generator.records = int64(generator.position() / format.alignment(newLine))
+		generator.record = format.record(generator.record[:0], generator, newLine)
+		generator.written += copy(generator.bytes[generator.written:], generator.record)
+	}
+}
+
+func (format *tFixedFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	offset := len(dst)
+	for i := 0; i < format.length; i++ {
+		dst = append(dst, format.pad)
+	}
+	for i, column := range format.schema.Columns {
+		field := dst[offset+*column.Offset : offset+*column.Offset+column.Width]
+		for j := range field {
+			field[j] = format.pads[i]
+		}
+		if !column.isNull(generator) {
+			generator.scratch = column.appendValue(generator.scratch[:0], generator, newLine)
+			value := generator.scratch
+			if column.Align == "right" {
+				// like COBOL numbers, high order characters are truncated
+				if len(value) > len(field) {
+					value = value[len(value)-len(field):]
+				}
+				copy(field[len(field)-len(value):], value)
+			} else {
+				copy(field, value)
+			}
+		}
+	}
+	if format.newLine {
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
@@ -0,0 +1,58 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAlignBuffer(t *testing.T) {
+	format := &tFixedFormat{length: 10, newLine: true}
+	if alignBuffer(format, 100, []byte{'\r', '\n'}) != 96 || alignBuffer(format, 5, []byte{'\n'}) != 11 {
+		t.Error("wrong alignment")
+	}
+	if alignBuffer(new(tText), 100, []byte{'\n'}) != 100 {
+		t.Error("text aligned")
+	}
+}
+
+func TestFixedRecords(t *testing.T) {
+	schema, err := readSchema("", columnsFixedDefault())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	format := &tFixedFormat{schema: schema, newLine: true, pad: '.'}
+	err = format.initLayout()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	newLine := []byte{'\r', '\n'}
+	output := generateTest(format, 10000, alignBuffer(format, 1000, newLine), newLine)
+	lines := bytes.Split(output, newLine)
+	for _, line := range lines[:len(lines)-1] {
+		if len(line) != format.length || !bytes.HasPrefix(line, []byte("0000")) {
+			t.Error("wrong record:", string(line))
+		}
+	}
+}
+
+func TestFixedOverlap(t *testing.T) {
+	columns := []*tColumn{newColumn("a", "word"), newColumn("b", "word")}
+	columns[0].Width, columns[1].Width = 5, 5
+	columns[1].Offset = new(int)
+	*columns[1].Offset = 4
+	schema, err := readSchema("", columns)
+	if err == nil {
+		format := &tFixedFormat{schema: schema}
+		err = format.initLayout()
+	}
+	if err == nil {
+		t.Error("overlap not recognized")
+	}
+}
@@ -0,0 +1,294 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+)
+
+const (
+	// recordATTEMPTS is the number of records generated to find one, that
+	// fits into the remaining space of a buffer without stretch point.
+	recordATTEMPTS = 64
+	// recordSAMPLES is the number of records checked before generation.
+	recordSAMPLES = 64
+)
+
+// tFormat fills the buffer of a generator.
+type tFormat interface {
+	fill(generator *tGenerator, newLine []byte)
+}
+
+// tRecordFormat generates output as a sequence of records. Records are
+// never split between buffers. Head is written at the beginning of output,
+// tail at the end. A record marks the point, where it can be stretched (see
+// markStretch). Space in buffer, that is too small for the next record, is
+// inserted at the last point of buffer. Without point space is filled with
+// blank lines.
+type tRecordFormat interface {
+	head(dst []byte, generator *tGenerator, newLine []byte) []byte
+	record(dst []byte, generator *tGenerator, newLine []byte) []byte
+	tail(dst []byte, generator *tGenerator, newLine []byte) []byte
+}
+
+// tPaddedFormat is a record format, that fills space between records
+// other than with blank lines.
+type tPaddedFormat interface {
+	pad(dst []byte, generator *tGenerator, newLine []byte)
+}
+
+// tClosingFormat is a record format, that completes the records of a
+// buffer, e.g. terminates a statement.
+type tClosingFormat interface {
+	close(generator *tGenerator, newLine []byte)
+}
+
+// tSequentialFormat is a record format, that numbers records. Records are
+// counted by generator across buffers, i.e. output can't be generated in
+// threads.
+type tSequentialFormat interface {
+	sequential() bool
+}
+
+// tAlignedFormat is a format, that needs buffers of a multiple of
+// alignment.
+type tAlignedFormat interface {
+	alignment(newLine []byte) int
+}
+
+type tText struct {
+}
+
+type tRecords struct {
+	format  tRecordFormat
+	corrupt float64
+}
+
+// tStretch is the offset in a record, where bytes of fill can be inserted
+// without breaking the record. Offset is negative, if record can't be
+// stretched.
+type tStretch struct {
+	offset int
+	fill   func(*rand.Rand, []byte)
+}
+
+func (text *tText) fill(generator *tGenerator, newLine []byte) {
+	generator.generateText(newLine)
+}
+
+func newRecords(format tRecordFormat) *tRecords {
+	records := new(tRecords)
+	records.format = format
+	return records
+}
+
+func (records *tRecords) fill(generator *tGenerator, newLine []byte) {
+	stretch, stretchTail := tStretch{offset: -1}, tStretch{offset: -1}
+	limit := len(generator.bytes)
+	if generator.last() {
+		generator.stretch = tStretch{offset: -1}
+		tail := records.format.tail(nil, generator, newLine)
+		if len(tail) > len(generator.bytes) {
+			tail = tail[len(tail)-len(generator.bytes):]
+		} else if generator.stretch.offset >= 0 {
+			stretchTail = generator.stretch
+			stretchTail.offset += limit - len(tail)
+		}
+		limit -= copy(generator.bytes[limit-len(tail):], tail)
+	}
+	if generator.first() {
+		generator.stretch = tStretch{offset: -1}
+		generator.record = records.format.head(generator.record[:0], generator, newLine)
+		generator.written += copy(generator.bytes[:limit], generator.record)
+		if generator.stretch.offset >= 0 && generator.written == len(generator.record) {
+			stretch = generator.stretch
+		}
+	}
+	records.fillRecords(generator, newLine, limit, stretch, stretchTail)
+	if closing, ok := records.format.(tClosingFormat); ok {
+		closing.close(generator, newLine)
+	}
+	if padded, ok := records.format.(tPaddedFormat); ok {
+		padded.pad(generator.bytes[generator.written:limit], generator, newLine)
+	} else {
+		fillBlank(generator.bytes[generator.written:limit], newLine)
+	}
+	generator.written = len(generator.bytes)
+}
+
+// fillRecords writes records up to limit and stretches the last record,
+// that can be stretched, by the remaining space. Head or tail are
+// stretched, if no record can be. Space remains, if neither can be.
+func (records *tRecords) fillRecords(generator *tGenerator, newLine []byte, limit int, stretch, stretchTail tStretch) {
+	for attempts := 0; generator.written < limit && generator.err == nil; {
+		var index int
+		var kind string
+		generator.stretch = tStretch{offset: -1}
+		generator.record = records.format.record(generator.record[:0], generator, newLine)
+		generator.record, index, kind = records.corruptRecord(generator.record, generator, newLine)
+		if len(generator.record) > cap(generator.bytes) {
+			generator.err = errors.New("record of " + strconv.Itoa(len(generator.record)) + " bytes is larger than buffer (increase --buffer)")
+		} else if len(generator.record) > 0 && len(generator.record) <= limit-generator.written {
+			if index >= 0 {
+				generator.defects = append(generator.defects, tDefect{generator.position() + index, kind})
+			} else if generator.stretch.offset >= 0 {
+				stretch = generator.stretch
+				stretch.offset += generator.written
+			}
+			generator.written += copy(generator.bytes[generator.written:], generator.record)
+			generator.records++
+		} else if stretch.offset >= 0 {
+			generator.stretchBuffer(stretch, limit)
+		} else if attempts++; attempts > recordATTEMPTS && stretchTail.offset >= 0 {
+			generator.stretchBuffer(stretchTail, limit)
+		} else if attempts > recordATTEMPTS {
+			return
+		}
+	}
+}
+
+// stretchBuffer inserts the space between written bytes and limit at the
+// stretch point, i.e. moves the bytes between point and space, and fills
+// the space. Point is in records or in tail, that begins at limit.
+func (generator *tGenerator) stretchBuffer(stretch tStretch, limit int) {
+	length := limit - generator.written
+	if stretch.offset >= limit {
+		copy(generator.bytes[generator.written:], generator.bytes[limit:stretch.offset])
+		stretch.offset -= length
+	} else {
+		copy(generator.bytes[stretch.offset+length:limit], generator.bytes[stretch.offset:generator.written])
+		for i := range generator.defects {
+			if generator.defects[i].offset >= generator.offset+stretch.offset {
+				generator.defects[i].offset += length
+			}
+		}
+	}
+	stretch.fill(generator.random, generator.bytes[stretch.offset:stretch.offset+length])
+	generator.written = limit
+}
+
+// markStretch marks offset in the current record as point, where bytes of
+// fill can be inserted. The last mark of a record counts.
+func (generator *tGenerator) markStretch(offset int, fill func(*rand.Rand, []byte)) {
+	generator.stretch = tStretch{offset, fill}
+}
+
+// checkRecords generates sample records, so that records, that don't fit
+// into buffers, are reported before output is created.
+func checkRecords(content *tContent, sizeBuffer int, newLine []byte, err error) error {
+	if records, ok := content.format.(*tRecords); ok && err == nil {
+		generator := newGenerator(0, content)
+		generator.locate(0, sizeBuffer)
+		head := records.format.head(nil, generator, newLine)
+		for i := 0; i < recordSAMPLES && generator.err == nil; i++ {
+			generator.record = records.format.record(generator.record[:0], generator, newLine)
+			if len(head)+len(generator.record) > sizeBuffer {
+				return errors.New("record of " + strconv.Itoa(len(generator.record)) + " bytes is larger than buffer (increase --buffer)")
+			}
+		}
+		return generator.err
+	}
+	return err
+}
+
+// checkSequential returns an error, if records are numbered and more than
+// one thread is requested.
+func checkSequential(content *tContent, maxThreads int, err error) error {
+	if records, ok := content.format.(*tRecords); ok && err == nil && maxThreads > 1 {
+		if sequential, ok := records.format.(tSequentialFormat); ok && sequential.sequential() {
+			return errors.New("seq needs one thread (-t=1)")
+		}
+	}
+	return err
+}
+
+// alignBuffer returns size of buffer aligned to format.
+func alignBuffer(format tFormat, sizeBuffer int, newLine []byte) int {
+	if aligned, ok := format.(tAlignedFormat); ok {
+		alignment := aligned.alignment(newLine)
+		if sizeBuffer > alignment {
+			return sizeBuffer - sizeBuffer%alignment
+		}
+		return alignment
+	}
+	return sizeBuffer
+}
+
+// appendRandom appends length bytes generated by randomFill.
+func (generator *tGenerator) appendRandom(dst []byte, length int, randomFill func(*rand.Rand, []byte)) []byte {
+	lengthDst := len(dst)
+	for i := 0; i < length; i++ {
+		dst = append(dst, 0)
+	}
+	randomFill(generator.random, dst[lengthDst:])
+	return dst
+}
+
+// appendWord appends a word of random length.
+func (generator *tGenerator) appendWord(dst []byte) []byte {
+	return generator.appendRandom(dst, generator.randWordLength(wordLEN_MAX), generator.randomFill)
+}
+
+// appendWords appends words separated by space.
+func (generator *tGenerator) appendWords(dst []byte, words int) []byte {
+	for i := 0; i < words; i++ {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = generator.appendWord(dst)
+	}
+	return dst
+}
+
+// appendName appends a word of lower case letters.
+func (generator *tGenerator) appendName(dst []byte, lengthMin, lengthMax int) []byte {
+	length := lengthMin + generator.random.Intn(lengthMax-lengthMin+1)
+	return generator.appendRandom(dst, length, randomFillL)
+}
+
+// wordFill returns the function filling words, if words are letters only,
+// otherwise lower case letters, i.e. characters, that need no escaping.
+func (generator *tGenerator) wordFill() func(*rand.Rand, []byte) {
+	if generator.content.letters {
+		return generator.randomFill
+	}
+	return randomFillL
+}
+
+// isLetters returns true, if alphabet has letters only.
+func isLetters(alphabet []byte) bool {
+	for _, b := range alphabet {
+		if (b < 'a' || b > 'z') && (b < 'A' || b > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// fillBlank fills bytes with new lines. Spaces fill the remainder.
+func fillBlank(bytes, newLine []byte) {
+	spaces := len(bytes) % len(newLine)
+	fillSpaces(bytes[:spaces])
+	for i := spaces; i < len(bytes); i += len(newLine) {
+		copy(bytes[i:], newLine)
+	}
+}
+
+// fillSpaces fills bytes with spaces.
+func fillSpaces(bytes []byte) {
+	for i := range bytes {
+		bytes[i] = ' '
+	}
+}
+
+// randomFillSpaces fills bytes with spaces, i.e. whitespace between tokens.
+func randomFillSpaces(random *rand.Rand, bytes []byte) {
+	fillSpaces(bytes)
+}
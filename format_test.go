@@ -0,0 +1,72 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestRecordsStretched(t *testing.T) {
+	for _, sizeBuffer := range []int{2000, 2001, 3333} {
+		output := generateTest(newRecords(&tYAMLFormat{4, 4}), 50000, sizeBuffer, []byte{'\n'})
+		if len(output) != 50000 {
+			t.Error("wrong size:", len(output))
+		}
+		if bytes.IndexByte(output, 0) >= 0 || bytes.Contains(output, []byte("\n\n")) {
+			t.Error("buffer not filled by records:", sizeBuffer)
+		}
+	}
+}
+
+func TestRecordsStretchedByWords(t *testing.T) {
+	format := new(tTemplateFormat)
+	format.template = template.Must(template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{words 3}}\n"))
+	content := &tContent{seed: 1, randomFill: randomFillU, letters: true, format: newRecords(format)}
+	generator := newGenerator(1000, content)
+	generator.locate(0, len(generator.bytes))
+	generator.generate([]byte{'\n'})
+	for _, b := range generator.bytes {
+		if b != ' ' && b != '\n' && (b < 'A' || b > 'Z') {
+			t.Fatal("wrong character:", string(b))
+		}
+	}
+	if !isLetters(alphabetOf(randomFillA)) || isLetters(alphabetOf(randomFillZ)) {
+		t.Error("letters not recognized")
+	}
+}
+
+func TestRecordsPadded(t *testing.T) {
+	format := new(tTemplateFormat)
+	format.template = template.Must(template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{int 1 100}}\n"))
+	for _, sizeBuffer := range []int{1000, 1001, 3333} {
+		output := generateTest(newRecords(format), 10000, sizeBuffer, []byte{'\n'})
+		if len(output) != 10000 {
+			t.Error("wrong size:", len(output))
+		}
+		for _, line := range bytes.Split(output, []byte{'\n'}) {
+			if len(line) > 0 && (len(line) > 3 || line[0] < '1' || line[0] > '9') {
+				t.Fatal("wrong line:", string(line))
+			}
+		}
+	}
+}
+
+func TestRecordsLargerThanBuffer(t *testing.T) {
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	content.seed = 1
+	content.format = newRecords(&tCodeFormat{langC, 3, 6, "    "})
+	if checkRecords(content, 10000, []byte{'\n'}, nil) != nil {
+		t.Error("records rejected")
+	}
+	if checkRecords(content, 200, []byte{'\n'}, nil) == nil {
+		t.Error("records larger than buffer not rejected")
+	}
+}
@@ -0,0 +1,702 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+)
+
+const (
+	grammarDEPTH_DEFAULT  = 10
+	grammarDEPTH_INFINITE = 1 << 30
+)
+
+const (
+	grammarTERMINAL = iota
+	grammarRANGE
+	grammarSYMBOL
+	grammarSEQUENCE
+	grammarCHOICE
+	grammarOPTION
+	grammarREPEAT
+)
+
+const (
+	tokenEOF = iota
+	tokenIDENT
+	tokenSTRING
+	tokenNUMBER
+	tokenDEFINE
+	tokenRANGE
+	tokenOPERATOR
+)
+
+type tGrammar struct {
+	rules    map[string]*tGrammarRule
+	order    []*tGrammarRule
+	start    *tGrammarRule
+	maxDepth int
+}
+
+type tGrammarRule struct {
+	name     string
+	expr     *tGrammarExpr
+	minDepth int
+}
+
+type tGrammarExpr struct {
+	kind      int
+	text      []byte
+	rule      *tGrammarRule
+	items     []*tGrammarExpr
+	weights   []float64
+	repeatMin int
+	minDepth  int
+	line      int
+	fill      func(*rand.Rand, []byte)
+}
+
+type tGrammarToken struct {
+	kind int
+	text string
+	line int
+}
+
+type tGrammarParser struct {
+	grammar *tGrammar
+	tokens  []tGrammarToken
+	index   int
+}
+
+// tGrammarFormat generates derivations of a grammar. Records are stretched
+// by repetitions of single characters, buffers of other grammars are
+// padded with blank lines.
+type tGrammarFormat struct {
+	grammar *tGrammar
+}
+
+func interpretGrammar(params *tParameters) (tFormat, error) {
+	text, err := ioutil.ReadFile(params.grammar.Values[0])
+	if err == nil {
+		var grammar *tGrammar
+		var start string
+		if params.start.Available() {
+			start = params.start.Values[0]
+		}
+		grammar, err = parseGrammar(text, start)
+		if err == nil {
+			grammar.maxDepth, err = interpretDepth(params, grammarDEPTH_DEFAULT, err)
+			if err == nil {
+				format := new(tGrammarFormat)
+				format.grammar = grammar
+				return newRecords(format), nil
+			}
+		}
+		return nil, err
+	}
+	return nil, errors.New("can't read grammar file")
+}
+
+func (format *tGrammarFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func (format *tGrammarFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = format.grammar.expand(dst, format.grammar.start.expr, 0, generator)
+	return append(dst, newLine...)
+}
+
+func (format *tGrammarFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// parseGrammar parses EBNF or BNF. If start is empty, the first rule is
+// the start symbol.
+func parseGrammar(text []byte, start string) (*tGrammar, error) {
+	tokens, err := tokenizeGrammar(text)
+	if err == nil {
+		parser := new(tGrammarParser)
+		parser.grammar = new(tGrammar)
+		parser.grammar.rules = make(map[string]*tGrammarRule)
+		parser.tokens = tokens
+		for err == nil && parser.peek().kind != tokenEOF {
+			err = parser.parseRule()
+		}
+		if err == nil {
+			err = parser.grammar.link(start)
+		}
+		if err == nil {
+			return parser.grammar, nil
+		}
+	}
+	return nil, err
+}
+
+func tokenizeGrammar(text []byte) ([]tGrammarToken, error) {
+	var tokens []tGrammarToken
+	line := 1
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || (c == '/' && i+1 < len(text) && text[i+1] == '/'):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case c == '(' && i+1 < len(text) && text[i+1] == '*':
+			i += 2
+			for i+1 < len(text) && !(text[i] == '*' && text[i+1] == ')') {
+				if text[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i+1 >= len(text) {
+				return nil, grammarError(line, "comment not closed")
+			}
+			i += 2
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(text) && isIdentPart(text[j]) {
+				j++
+			}
+			tokens = append(tokens, tGrammarToken{tokenIDENT, string(text[i:j]), line})
+			i = j
+		case c == '<' && i+1 < len(text) && isIdentStart(text[i+1]):
+			j := i + 1
+			for j < len(text) && (isIdentPart(text[j]) || text[j] == ' ') {
+				j++
+			}
+			if j >= len(text) || text[j] != '>' {
+				return nil, grammarError(line, "symbol not closed")
+			}
+			tokens = append(tokens, tGrammarToken{tokenIDENT, string(text[i+1 : j]), line})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(text) && (text[j] >= '0' && text[j] <= '9' || text[j] == '.' && (j+1 >= len(text) || text[j+1] != '.')) {
+				j++
+			}
+			tokens = append(tokens, tGrammarToken{tokenNUMBER, string(text[i:j]), line})
+			i = j
+		case c == '"' || c == '\'':
+			str, length, err := unquoteGrammar(text[i:], line)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tGrammarToken{tokenSTRING, str, line})
+			i += length
+		case hasPrefix(text[i:], "::="):
+			tokens = append(tokens, tGrammarToken{tokenDEFINE, "::=", line})
+			i += 3
+		case hasPrefix(text[i:], ":="):
+			tokens = append(tokens, tGrammarToken{tokenDEFINE, ":=", line})
+			i += 2
+		case c == '=' || c == ':':
+			tokens = append(tokens, tGrammarToken{tokenDEFINE, string(c), line})
+			i++
+		case hasPrefix(text[i:], ".."):
+			tokens = append(tokens, tGrammarToken{tokenRANGE, "..", line})
+			i += 2
+		case isGrammarOperator(c):
+			tokens = append(tokens, tGrammarToken{tokenOPERATOR, string(c), line})
+			i++
+		default:
+			return nil, grammarError(line, "unexpected character '"+string(c)+"'")
+		}
+	}
+	tokens = append(tokens, tGrammarToken{tokenEOF, "", line})
+	return tokens, nil
+}
+
+func unquoteGrammar(text []byte, line int) (string, int, error) {
+	var str []byte
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		c := text[i]
+		if c == quote {
+			return string(str), i + 1, nil
+		} else if c == '\n' {
+			break
+		} else if c == '\\' && i+1 < len(text) {
+			i++
+			switch text[i] {
+			case 'n':
+				str = append(str, '\n')
+			case 'r':
+				str = append(str, '\r')
+			case 't':
+				str = append(str, '\t')
+			case 'x':
+				if i+2 < len(text) {
+					value, err := strconv.ParseUint(string(text[i+1:i+3]), 16, 8)
+					if err == nil {
+						str = append(str, byte(value))
+						i += 2
+						continue
+					}
+				}
+				return "", 0, grammarError(line, "bad escape sequence")
+			default:
+				str = append(str, text[i])
+			}
+		} else {
+			str = append(str, c)
+		}
+	}
+	return "", 0, grammarError(line, "string not closed")
+}
+
+func (parser *tGrammarParser) parseRule() error {
+	token := parser.next()
+	if token.kind != tokenIDENT {
+		return grammarError(token.line, "rule name expected")
+	}
+	if parser.next().kind != tokenDEFINE {
+		return grammarError(token.line, "definition expected after \""+token.text+"\"")
+	}
+	expr, err := parser.parseChoice()
+	if err == nil {
+		if parser.isOperator(";") || parser.isOperator(".") {
+			parser.next()
+		}
+		rule := parser.grammar.rules[token.text]
+		if rule == nil {
+			rule = new(tGrammarRule)
+			rule.name = token.text
+			rule.expr = expr
+			parser.grammar.rules[token.text] = rule
+			parser.grammar.order = append(parser.grammar.order, rule)
+		} else {
+			// rule defined twice, i.e. more alternatives
+			rule.expr = mergeChoices(rule.expr, expr)
+		}
+	}
+	return err
+}
+
+func (parser *tGrammarParser) parseChoice() (*tGrammarExpr, error) {
+	expr := newGrammarExpr(grammarCHOICE, parser.peek().line)
+	for {
+		sequence, err := parser.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		weight := 1.0
+		if parser.isOperator("@") {
+			parser.next()
+			token := parser.next()
+			weight, err = strconv.ParseFloat(token.text, 64)
+			if token.kind != tokenNUMBER || err != nil || weight < 0 {
+				return nil, grammarError(token.line, "weight expected after '@'")
+			}
+		}
+		expr.items = append(expr.items, sequence)
+		expr.weights = append(expr.weights, weight)
+		if parser.isOperator("|") || parser.isOperator("/") {
+			parser.next()
+		} else {
+			break
+		}
+	}
+	if len(expr.items) == 1 {
+		return expr.items[0], nil
+	}
+	return expr, nil
+}
+
+func (parser *tGrammarParser) parseSequence() (*tGrammarExpr, error) {
+	expr := newGrammarExpr(grammarSEQUENCE, parser.peek().line)
+	for !parser.sequenceEnds() {
+		if parser.isOperator(",") {
+			parser.next()
+		} else {
+			factor, err := parser.parseFactor()
+			if err != nil {
+				return nil, err
+			}
+			expr.items = append(expr.items, factor)
+		}
+	}
+	if len(expr.items) == 1 {
+		return expr.items[0], nil
+	}
+	return expr, nil
+}
+
+func (parser *tGrammarParser) parseFactor() (*tGrammarExpr, error) {
+	expr, err := parser.parsePrimary()
+	for err == nil && (parser.isOperator("?") || parser.isOperator("*") || parser.isOperator("+")) {
+		token := parser.next()
+		var wrapper *tGrammarExpr
+		if token.text == "?" {
+			wrapper = newGrammarExpr(grammarOPTION, token.line)
+		} else {
+			wrapper = newGrammarExpr(grammarREPEAT, token.line)
+			if token.text == "+" {
+				wrapper.repeatMin = 1
+			}
+		}
+		wrapper.items = append(wrapper.items, expr)
+		expr = wrapper
+	}
+	return expr, err
+}
+
+func (parser *tGrammarParser) parsePrimary() (*tGrammarExpr, error) {
+	token := parser.next()
+	switch token.kind {
+	case tokenIDENT:
+		expr := newGrammarExpr(grammarSYMBOL, token.line)
+		expr.text = []byte(token.text)
+		return expr, nil
+	case tokenSTRING:
+		if parser.peek().kind == tokenRANGE {
+			parser.next()
+			tokenTo := parser.next()
+			if tokenTo.kind != tokenSTRING || len(token.text) != 1 || len(tokenTo.text) != 1 || token.text[0] > tokenTo.text[0] {
+				return nil, grammarError(token.line, "bad character range")
+			}
+			expr := newGrammarExpr(grammarRANGE, token.line)
+			expr.text = []byte{token.text[0], tokenTo.text[0]}
+			return expr, nil
+		}
+		expr := newGrammarExpr(grammarTERMINAL, token.line)
+		expr.text = []byte(token.text)
+		return expr, nil
+	case tokenOPERATOR:
+		var kind int
+		var closing string
+		switch token.text {
+		case "(":
+			kind, closing = grammarSEQUENCE, ")"
+		case "[":
+			kind, closing = grammarOPTION, "]"
+		case "{":
+			kind, closing = grammarREPEAT, "}"
+		default:
+			return nil, grammarError(token.line, "unexpected '"+token.text+"'")
+		}
+		inner, err := parser.parseChoice()
+		if err == nil {
+			if !parser.isOperator(closing) {
+				return nil, grammarError(token.line, "'"+token.text+"' not closed")
+			}
+			parser.next()
+			if kind == grammarSEQUENCE {
+				return inner, nil
+			}
+			expr := newGrammarExpr(kind, token.line)
+			expr.items = append(expr.items, inner)
+			return expr, nil
+		}
+		return nil, err
+	}
+	return nil, grammarError(token.line, "unexpected \""+token.text+"\"")
+}
+
+func (parser *tGrammarParser) sequenceEnds() bool {
+	token := parser.peek()
+	switch token.kind {
+	case tokenEOF:
+		return true
+	case tokenOPERATOR:
+		switch token.text {
+		case "|", "/", ")", "]", "}", ";", ".", "@":
+			return true
+		}
+	case tokenIDENT:
+		// next rule begins
+		return parser.tokens[parser.index+1].kind == tokenDEFINE
+	}
+	return false
+}
+
+func (parser *tGrammarParser) peek() tGrammarToken {
+	return parser.tokens[parser.index]
+}
+
+func (parser *tGrammarParser) next() tGrammarToken {
+	token := parser.tokens[parser.index]
+	if token.kind != tokenEOF {
+		parser.index++
+	}
+	return token
+}
+
+func (parser *tGrammarParser) isOperator(operator string) bool {
+	token := parser.peek()
+	return token.kind == tokenOPERATOR && token.text == operator
+}
+
+// link resolves symbols and computes the minimum depth of expressions.
+func (grammar *tGrammar) link(start string) error {
+	if len(grammar.order) == 0 {
+		return errors.New("grammar has no rules")
+	}
+	for _, rule := range grammar.order {
+		err := grammar.linkExpr(rule.expr)
+		if err != nil {
+			return err
+		}
+		rule.minDepth = grammarDEPTH_INFINITE
+	}
+	if len(start) == 0 {
+		grammar.start = grammar.order[0]
+	} else {
+		grammar.start = grammar.rules[start]
+		if grammar.start == nil {
+			return errors.New("start symbol \"" + start + "\" not defined in grammar")
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range grammar.order {
+			minDepth := computeMinDepth(rule.expr)
+			if minDepth < rule.minDepth {
+				rule.minDepth = minDepth
+				changed = true
+			}
+		}
+	}
+	if grammar.start.minDepth >= grammarDEPTH_INFINITE {
+		return errors.New("symbol \"" + grammar.start.name + "\" never terminates")
+	}
+	for _, rule := range grammar.order {
+		linkFill(rule.expr)
+	}
+	return nil
+}
+
+// linkFill sets fill of repetitions of single characters, e.g. digits of a
+// number. Records are stretched by these repetitions.
+func linkFill(expr *tGrammarExpr) {
+	if expr.kind == grammarREPEAT && isCharacter(expr.items[0], 0) {
+		item := expr.items[0]
+		expr.fill = func(random *rand.Rand, bytes []byte) {
+			for i := range bytes {
+				bytes[i] = expandCharacter(item, random)
+			}
+		}
+	}
+	for _, item := range expr.items {
+		linkFill(item)
+	}
+}
+
+// isCharacter returns true, if expr expands to exactly one character.
+func isCharacter(expr *tGrammarExpr, depth int) bool {
+	switch expr.kind {
+	case grammarTERMINAL:
+		return len(expr.text) == 1
+	case grammarRANGE:
+		return true
+	case grammarSYMBOL:
+		// symbols may be recursive
+		return depth < grammarDEPTH_DEFAULT && isCharacter(expr.rule.expr, depth+1)
+	case grammarSEQUENCE:
+		return len(expr.items) == 1 && isCharacter(expr.items[0], depth)
+	case grammarCHOICE:
+		for _, item := range expr.items {
+			if !isCharacter(item, depth) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// expandCharacter returns the character of expr, see isCharacter.
+func expandCharacter(expr *tGrammarExpr, random *rand.Rand) byte {
+	for {
+		switch expr.kind {
+		case grammarTERMINAL:
+			return expr.text[0]
+		case grammarRANGE:
+			return expr.text[0] + byte(random.Intn(int(expr.text[1]-expr.text[0])+1))
+		case grammarSYMBOL:
+			expr = expr.rule.expr
+		case grammarSEQUENCE:
+			expr = expr.items[0]
+		default:
+			expr = chooseAlternative(expr, grammarDEPTH_INFINITE, random)
+		}
+	}
+}
+
+func (grammar *tGrammar) linkExpr(expr *tGrammarExpr) error {
+	if expr.kind == grammarSYMBOL {
+		expr.rule = grammar.rules[string(expr.text)]
+		if expr.rule == nil {
+			return grammarError(expr.line, "symbol \""+string(expr.text)+"\" not defined")
+		}
+	}
+	for _, item := range expr.items {
+		err := grammar.linkExpr(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func computeMinDepth(expr *tGrammarExpr) int {
+	switch expr.kind {
+	case grammarSYMBOL:
+		expr.minDepth = expr.rule.minDepth
+		if expr.minDepth < grammarDEPTH_INFINITE {
+			expr.minDepth++
+		}
+	case grammarSEQUENCE:
+		expr.minDepth = 0
+		for _, item := range expr.items {
+			minDepth := computeMinDepth(item)
+			if minDepth > expr.minDepth {
+				expr.minDepth = minDepth
+			}
+		}
+	case grammarCHOICE:
+		expr.minDepth = grammarDEPTH_INFINITE
+		for _, item := range expr.items {
+			minDepth := computeMinDepth(item)
+			if minDepth < expr.minDepth {
+				expr.minDepth = minDepth
+			}
+		}
+	case grammarOPTION, grammarREPEAT:
+		minDepth := computeMinDepth(expr.items[0])
+		if expr.repeatMin > 0 {
+			expr.minDepth = minDepth
+		} else {
+			expr.minDepth = 0
+		}
+	default:
+		expr.minDepth = 0
+	}
+	return expr.minDepth
+}
+
+// expand appends a random sentence derived from expr. Beyond maximum depth
+// only expressions are chosen, that terminate as fast as possible.
+func (grammar *tGrammar) expand(dst []byte, expr *tGrammarExpr, depth int, generator *tGenerator) []byte {
+	random := generator.random
+	budget := grammar.maxDepth - depth
+	switch expr.kind {
+	case grammarTERMINAL:
+		dst = append(dst, expr.text...)
+	case grammarRANGE:
+		dst = append(dst, expr.text[0]+byte(random.Intn(int(expr.text[1]-expr.text[0])+1)))
+	case grammarSYMBOL:
+		dst = grammar.expand(dst, expr.rule.expr, depth+1, generator)
+	case grammarSEQUENCE:
+		for _, item := range expr.items {
+			dst = grammar.expand(dst, item, depth, generator)
+		}
+	case grammarCHOICE:
+		dst = grammar.expand(dst, chooseAlternative(expr, budget, random), depth, generator)
+	case grammarOPTION:
+		if expr.items[0].minDepth <= budget && random.Intn(2) == 0 {
+			dst = grammar.expand(dst, expr.items[0], depth, generator)
+		}
+	case grammarREPEAT:
+		repeat := expr.repeatMin
+		if expr.items[0].minDepth <= budget {
+			for random.Intn(2) == 0 {
+				repeat++
+			}
+		}
+		for i := 0; i < repeat; i++ {
+			dst = grammar.expand(dst, expr.items[0], depth, generator)
+		}
+		if expr.fill != nil {
+			generator.markStretch(len(dst), expr.fill)
+		}
+	}
+	return dst
+}
+
+func chooseAlternative(expr *tGrammarExpr, budget int, random *rand.Rand) *tGrammarExpr {
+	var weightsTotal float64
+	shortest := expr.items[0]
+	for i, item := range expr.items {
+		if item.minDepth <= budget {
+			weightsTotal += expr.weights[i]
+		}
+		if item.minDepth < shortest.minDepth {
+			shortest = item
+		}
+	}
+	if weightsTotal > 0 {
+		weight := random.Float64() * weightsTotal
+		for i, item := range expr.items {
+			if item.minDepth <= budget {
+				weight -= expr.weights[i]
+				if weight < 0 {
+					return item
+				}
+			}
+		}
+	}
+	return shortest
+}
+
+func mergeChoices(exprA, exprB *tGrammarExpr) *tGrammarExpr {
+	if exprA.kind != grammarCHOICE {
+		choice := newGrammarExpr(grammarCHOICE, exprA.line)
+		choice.items = append(choice.items, exprA)
+		choice.weights = append(choice.weights, 1)
+		exprA = choice
+	}
+	if exprB.kind == grammarCHOICE {
+		exprA.items = append(exprA.items, exprB.items...)
+		exprA.weights = append(exprA.weights, exprB.weights...)
+	} else {
+		exprA.items = append(exprA.items, exprB)
+		exprA.weights = append(exprA.weights, 1)
+	}
+	return exprA
+}
+
+func newGrammarExpr(kind, line int) *tGrammarExpr {
+	expr := new(tGrammarExpr)
+	expr.kind = kind
+	expr.line = line
+	return expr
+}
+
+func grammarError(line int, message string) error {
+	return errors.New("grammar, line " + strconv.Itoa(line) + ": " + message)
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9' || c == '-'
+}
+
+func isGrammarOperator(c byte) bool {
+	switch c {
+	case '|', '/', '(', ')', '[', ']', '{', '}', '?', '*', '+', ',', ';', '.', '@':
+		return true
+	}
+	return false
+}
+
+func hasPrefix(bytes []byte, prefix string) bool {
+	return len(bytes) >= len(prefix) && string(bytes[:len(prefix)]) == prefix
+}
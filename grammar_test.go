@@ -0,0 +1,76 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseGrammar(t *testing.T) {
+	text := "(* comment *)\nList = Item { \",\" Item } ;\nItem = \"a\" @ 3 | \"b\" | Digit ;\nDigit = \"0\" .. \"9\" ;\n"
+	grammar, err := parseGrammar([]byte(text), "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if grammar.start.name != "List" {
+		t.Error("wrong start symbol:", grammar.start.name)
+	}
+	if grammar.rules["Item"].minDepth != 0 || grammar.start.minDepth != 1 {
+		t.Error("wrong minimum depth")
+	}
+	text = "<list> ::= <item> | <item> \",\" <list>\n<item> ::= 'x'\n"
+	grammar, err = parseGrammar([]byte(text), "list")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	grammar.maxDepth = 1
+	sentence := string(grammar.expand(nil, grammar.start.expr, 0, newGenerator(0, &tContent{seed: 1})))
+	if sentence != "x" && sentence != "x,x" {
+		t.Error("depth limit ignored:", sentence)
+	}
+}
+
+func TestParseGrammarErrors(t *testing.T) {
+	texts := []string{"A = B ;", "A = \"a", "A = A \"a\" ;", "A = ( \"a\" ;", "= \"a\""}
+	for _, text := range texts {
+		_, err := parseGrammar([]byte(text), "")
+		if err == nil {
+			t.Error("invalid grammar not recognized:", text)
+		}
+	}
+	_, err := parseGrammar([]byte("A = \"a\" ;"), "B")
+	if err == nil {
+		t.Error("undefined start symbol not recognized")
+	}
+}
+
+func TestGrammarPadded(t *testing.T) {
+	texts := []string{
+		"Expr = Term { ( \"+\" | \"-\" ) Term } ;\nTerm = Factor { ( \"*\" | \"/\" ) Factor } ;\nFactor = \"x\" | \"y\" | \"(\" Expr \")\" ;\n",
+		"<expr> ::= <term> | <expr> \"+\" <term>\n<term> ::= <factor> | <term> \"*\" <factor>\n<factor> ::= \"x\" | \"y\" | \"(\" <expr> \")\"\n"}
+	for _, text := range texts {
+		grammar, err := parseGrammar([]byte(text), "")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		grammar.maxDepth = grammarDEPTH_DEFAULT
+		format := newRecords(&tGrammarFormat{grammar})
+		err = checkRecords(&tContent{seed: 1, randomFill: randomFillZ, format: format}, 1000, []byte{'\n'}, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		output := generateTest(format, 10000, 1000, []byte{'\n'})
+		if len(output) != 10000 {
+			t.Error("wrong size:", len(output))
+		}
+		if len(bytes.Trim(output, "xy+-*/()\n")) > 0 {
+			t.Error("sentences not padded with new lines:", string(output))
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// tHole is a run of NUL bytes at offset, that repeats every period bytes,
+// if period is greater than 0.
+type tHole struct {
+	offset int
+	size   int
+	period int
+}
+
+// tHoles writes NUL bytes into output of format. Holes are sparse, i.e.
+// skipped when output is written to file.
+type tHoles struct {
+	format tFormat
+	holes  []tHole
+	sparse bool
+}
+
+func interpretHoles(params *tParameters, format tFormat, err error) (tFormat, error) {
+	if err == nil && params.holes.Available() {
+		holes := &tHoles{format: format, sparse: params.sparse.Available()}
+		holes.holes, err = parseHoles(params.holes.Values[0])
+		if err == nil && holes.sparse && !params.outputToFile() {
+			return nil, errors.New("sparse needs an output file")
+		} else if err == nil {
+			return holes, nil
+		}
+		return nil, err
+	} else if err == nil && params.sparse.Available() {
+		return nil, errors.New("sparse needs holes")
+	}
+	return format, err
+}
+
+// parseHoles parses "offset:size[:period],...", e.g. "0:4K,1M:64K:10M".
+func parseHoles(str string) ([]tHole, error) {
+	var holes []tHole
+	for _, hole := range strings.Split(str, ",") {
+		values := strings.Split(strings.TrimSpace(hole), ":")
+		if len(values) == 2 {
+			values = append(values, "0")
+		}
+		if len(values) == 3 {
+			offset, errOffset := parseBytes(values[0])
+			size, errSize := parseBytes(values[1])
+			period, errPeriod := parseBytes(values[2])
+			if errOffset == nil && errSize == nil && errPeriod == nil && offset >= 0 && size > 0 && (period == 0 || period >= size) {
+				holes = append(holes, tHole{offset, size, period})
+				continue
+			}
+		}
+		return nil, errors.New("can't parse hole \"" + hole + "\" (expected offset:size or offset:size:period)")
+	}
+	return holes, nil
+}
+
+func (holes *tHoles) alignment(newLine []byte) int {
+	if aligned, ok := holes.format.(tAlignedFormat); ok {
+		return aligned.alignment(newLine)
+	}
+	return 1
+}
+
+func (holes *tHoles) fill(generator *tGenerator, newLine []byte) {
+	holes.format.fill(generator, newLine)
+	generator.holes = generator.holes[:0]
+	begin, end := generator.offset, generator.offset+len(generator.bytes)
+	for _, hole := range holes.holes {
+		offset := hole.offset
+		if hole.period > 0 && begin > offset {
+			offset += (begin - offset) / hole.period * hole.period
+		}
+		for ; offset < end; offset += hole.period {
+			if offset+hole.size > begin {
+				generator.holes = append(generator.holes, tHole{maxInt(offset, begin) - begin, minInt(offset+hole.size, end) - maxInt(offset, begin), 0})
+			}
+			if hole.period == 0 {
+				break
+			}
+		}
+	}
+	generator.holes = mergeHoles(generator.holes)
+	for _, hole := range generator.holes {
+		zero := generator.bytes[hole.offset : hole.offset+hole.size]
+		for i := range zero {
+			zero[i] = 0
+		}
+	}
+	if !holes.sparse {
+		generator.holes = generator.holes[:0]
+	}
+}
+
+// mergeHoles returns holes sorted by offset, without overlaps.
+func mergeHoles(holes []tHole) []tHole {
+	sort.Slice(holes, func(i, j int) bool { return holes[i].offset < holes[j].offset })
+	merged := holes[:0]
+	for _, hole := range holes {
+		if n := len(merged); n > 0 && hole.offset <= merged[n-1].offset+merged[n-1].size {
+			merged[n-1].size = maxInt(merged[n-1].size, hole.offset+hole.size-merged[n-1].offset)
+		} else {
+			merged = append(merged, hole)
+		}
+	}
+	return merged
+}
+
+// writeSparse writes buffer to file and seeks over holes. File is extended,
+// if output ends with a hole.
+func (generator *tGenerator) writeSparse(out *os.File) error {
+	var err error
+	var written int
+	for _, hole := range generator.holes {
+		if err == nil {
+			_, err = out.Write(generator.bytes[written:hole.offset])
+		}
+		if err == nil {
+			_, err = out.Seek(int64(hole.size), io.SeekCurrent)
+		}
+		written = hole.offset + hole.size
+	}
+	if err == nil && written < len(generator.bytes) {
+		_, err = out.Write(generator.bytes[written:])
+	} else if err == nil && generator.last() {
+		err = out.Truncate(int64(generator.offset + len(generator.bytes)))
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestParseHoles(t *testing.T) {
+	holes, err := parseHoles("0:4K, 1M:64K:10M")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(holes) != 2 || holes[0] != (tHole{0, 4000, 0}) || holes[1] != (tHole{1000000, 64000, 10000000}) {
+		t.Error("wrong holes:", holes)
+	}
+	for _, str := range []string{"1", "1:0", "-1:1", "0:5:4", "a:1"} {
+		if _, err = parseHoles(str); err == nil {
+			t.Error("no error for", str)
+		}
+	}
+}
+
+func TestHoles(t *testing.T) {
+	format := &tHoles{new(tBinary), []tHole{{100, 50, 1000}, {120, 100, 0}, {9990, 100, 0}}, false}
+	output := generateTest(format, 10000, 333, []byte{'\n'})
+	for i, b := range output {
+		hole := i >= 9990 || i >= 100 && i < 220 || i%1000 >= 100 && i%1000 < 150
+		if hole && b != 0 {
+			t.Fatal("wrong byte at", i)
+		}
+	}
+}
+
+func TestWriteSparse(t *testing.T) {
+	file, err := ioutil.TempFile("", "textgen")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	content := new(tContent)
+	content.format = &tHoles{new(tBinary), []tHole{{10, 20, 0}, {90, 20, 0}}, true}
+	generator := newGenerator(50, content)
+	for offset := 0; offset < 100 && err == nil; offset += 50 {
+		generator.locate(offset, 100)
+		generator.generate(nil)
+		err = generator.writeFile(file)
+	}
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	written, _ := ioutil.ReadFile(file.Name())
+	if len(written) != 100 || !bytes.Equal(written[90:], make([]byte, 10)) || !bytes.Equal(written[10:30], make([]byte, 20)) {
+		t.Error("wrong output:", written)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	indentNONE   = ""
+	indentTABS   = "tabs"
+	indentSPACES = "spaces"
+	indentMIXED  = "mixed"
+)
+
+const (
+	indentWIDTH_DEFAULT = 4
+	indentDEPTH_MAX     = 8
+	indentTRAILING_MAX  = 3
+)
+
+// tIndentedText is text with indented lines, tabs between words and
+// trailing whitespace. Depth of indentation is a random walk, i.e. it
+// changes by one level at most from line to line, like nested code. Depth
+// returns to 0 at the end of a buffer, so the walk continues in the next
+// buffer, regardless of threads.
+type tIndentedText struct {
+	indent   string
+	width    int
+	tabs     float64
+	trailing float64
+}
+
+func interpretIndent(params *tParameters, format tFormat, err error) (tFormat, error) {
+	if err == nil && (params.indent.Available() || params.tabs.Available() || params.trailing.Available()) {
+		if _, ok := format.(*tText); !ok {
+			return nil, errors.New("indent, tabs and trailing need text output")
+		}
+		text := &tIndentedText{indent: indentNONE, width: indentWIDTH_DEFAULT}
+		if params.indent.Available() {
+			modeWidth := strings.SplitN(params.indent.Values[0], ":", 2)
+			text.indent = strings.ToLower(modeWidth[0])
+			if text.indent != indentTABS && text.indent != indentSPACES && text.indent != indentMIXED {
+				return nil, errors.New("unknown indent \"" + modeWidth[0] + "\" (expected tabs, spaces or mixed)")
+			}
+			if len(modeWidth) == 2 {
+				text.width, err = strconv.Atoi(modeWidth[1])
+				if err != nil || text.width <= 0 || text.indent == indentTABS {
+					return nil, errors.New("can't parse width of indent (expected spaces:N or mixed:N)")
+				}
+			}
+		}
+		text.tabs, err = interpretRate(params.tabs, "tabs", err)
+		text.trailing, err = interpretRate(params.trailing, "trailing", err)
+		return text, err
+	}
+	return format, err
+}
+
+// fill writes lines. Depth decreases, while the remaining space is less
+// than depth+2 lines of maximum length, so the line continued in the next
+// buffer starts at depth 0.
+func (text *tIndentedText) fill(generator *tGenerator, newLine []byte) {
+	var depth int
+	line := !generator.first()
+	lengthMax := text.lineLengthMax(newLine)
+	for generator.written < len(generator.bytes) {
+		generator.record = text.appendLine(generator.record[:0], generator, depth, line, newLine)
+		if len(generator.record) > len(generator.bytes)-generator.written {
+			// line continues in next buffer, without trailing whitespace
+			body := bytes.TrimRight(generator.record[:len(generator.record)-len(newLine)], " \t")
+			generator.written += copy(generator.bytes[generator.written:], body)
+			text.fillWords(generator.bytes[generator.written:], generator)
+			generator.written = len(generator.bytes)
+			if end := generator.bytes[generator.written-1:]; generator.last() && (end[0] == ' ' || end[0] == '\t') {
+				// output doesn't end with whitespace
+				generator.randomFill(generator.random, end)
+			}
+		} else {
+			generator.written += copy(generator.bytes[generator.written:], generator.record)
+		}
+		line = false
+		if !generator.last() && len(generator.bytes)-generator.written < (depth+2)*lengthMax {
+			depth = maxInt(depth-1, 0)
+		} else if random := generator.random.Intn(3); random == 0 && depth < indentDEPTH_MAX {
+			depth++
+		} else if random == 1 && depth > 0 {
+			depth--
+		}
+	}
+}
+
+// lineLengthMax returns the maximum length of lines.
+func (text *tIndentedText) lineLengthMax(newLine []byte) int {
+	return indentDEPTH_MAX*maxInt(text.width, 1) + wordsPerLineMAX*(wordLEN_MAX+1) + indentTRAILING_MAX + len(newLine)
+}
+
+// appendLine appends indentation, words and trailing whitespace. Line isn't
+// indented, if it continues the line of the previous buffer.
+func (text *tIndentedText) appendLine(dst []byte, generator *tGenerator, depth int, continued bool, newLine []byte) []byte {
+	if !continued {
+		dst = text.appendIndent(dst, generator, depth)
+	}
+	for words := 1; ; words++ {
+		dst = generator.appendRandom(dst, generator.randWordLength(wordLEN_MAX), generator.randomFill)
+		if generator.randLineBreak(words) {
+			break
+		}
+		dst = append(dst, text.separator(generator))
+	}
+	if text.trailing > 0 && generator.random.Float64() < text.trailing {
+		for i := generator.random.Intn(indentTRAILING_MAX); i >= 0; i-- {
+			dst = append(dst, " \t"[generator.random.Intn(2)])
+		}
+	}
+	return append(dst, newLine...)
+}
+
+// fillWords fills the end of a line, that follows a word, with separators
+// and words like fillLine.
+func (text *tIndentedText) fillWords(line []byte, generator *tGenerator) {
+	if len(line) > 0 {
+		line[0] = text.separator(generator)
+		generator.fillLine(line[1:], false)
+		for i := range line[1:] {
+			if line[1+i] == ' ' {
+				line[1+i] = text.separator(generator)
+			}
+		}
+	}
+}
+
+// separator returns tab at the rate of tabs, otherwise space.
+func (text *tIndentedText) separator(generator *tGenerator) byte {
+	if text.tabs > 0 && generator.random.Float64() < text.tabs {
+		return '\t'
+	}
+	return ' '
+}
+
+// appendIndent appends depth levels of tabs or spaces. Mixed levels are
+// either a tab or spaces.
+func (text *tIndentedText) appendIndent(dst []byte, generator *tGenerator, depth int) []byte {
+	for i := 0; i < depth; i++ {
+		if text.indent == indentTABS || text.indent == indentMIXED && generator.random.Intn(2) == 0 {
+			dst = append(dst, '\t')
+		} else if text.indent != indentNONE {
+			for j := 0; j < text.width; j++ {
+				dst = append(dst, ' ')
+			}
+		}
+	}
+	return dst
+}
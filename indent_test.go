@@ -0,0 +1,73 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestIndentRandomWalk(t *testing.T) {
+	newLine := []byte{'\r', '\n'}
+	format := &tIndentedText{indent: indentSPACES, width: 2}
+	for _, sizeBuffer := range []int{1 << 20, 1 << 14, 1000} {
+		output := generateTest(format, 1<<20, sizeBuffer, newLine)
+		depthPrevious, depthMax := 0, 0
+		for i, line := range bytes.Split(output, newLine) {
+			depth := len(line) - len(bytes.TrimLeft(line, " "))
+			if depth%2 != 0 || depth > 2*indentDEPTH_MAX || math.Abs(float64(depth-depthPrevious)) > 2 {
+				t.Fatal("wrong indentation of line", i, depth, depthPrevious, sizeBuffer)
+			}
+			depthPrevious, depthMax = depth, maxInt(depthMax, depth)
+		}
+		if sizeBuffer > 1000 && depthMax < 6 {
+			t.Error("too little indentation:", depthMax, sizeBuffer)
+		}
+	}
+}
+
+func TestIndentTabs(t *testing.T) {
+	newLine := []byte{'\r', '\n'}
+	format := &tIndentedText{indent: indentTABS, tabs: 1, trailing: 1}
+	output := generateTest(format, 1<<20, 1000, newLine)
+	if len(output) != 1<<20 {
+		t.Error("wrong size:", len(output))
+	}
+	if bytes.Count(output, []byte{'\r'}) != bytes.Count(output, newLine) {
+		t.Error("new lines are split")
+	}
+	lines := bytes.Split(output, newLine)
+	for i, line := range lines[1 : len(lines)-1] {
+		if bytes.IndexByte(line, ' ') >= 0 && bytes.IndexByte(line, ' ') < len(line)-indentTRAILING_MAX {
+			t.Fatal("space in line", i, string(line))
+		}
+		if len(bytes.TrimRight(line, " \t")) == len(line) {
+			t.Fatal("no trailing whitespace in line", i)
+		}
+	}
+}
+
+func TestIndentBuffers(t *testing.T) {
+	newLine := []byte{'\n'}
+	format := &tIndentedText{indent: indentSPACES, width: 4, tabs: 0.5}
+	for _, sizeBuffer := range []int{1000, 1001, 4000} {
+		output := generateTest(format, 1<<16, sizeBuffer, newLine)
+		for i, line := range bytes.Split(output, newLine) {
+			if len(bytes.TrimRight(line, " \t")) != len(line) {
+				t.Fatal("trailing whitespace in line", i, sizeBuffer)
+			}
+			for _, word := range bytes.Fields(line) {
+				// words of two buffers are joined
+				if len(word) > 2*wordLEN_MAX {
+					t.Fatal("line not filled with words:", i, string(word))
+				}
+			}
+		}
+	}
+}
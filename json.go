@@ -0,0 +1,225 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+)
+
+const (
+	jsonDEPTH_DEFAULT  = 3
+	jsonFANOUT_DEFAULT = 6
+)
+
+// tJSONFormat generates an array of objects (JSON) or one object per
+// line (JSON Lines). The last object is generated as tail, so it has no
+// comma. Records are stretched by a key, a string or by whitespace.
+type tJSONFormat struct {
+	lines    bool
+	schema   *tSchema
+	maxDepth int
+	fanout   int
+}
+
+func interpretJSON(params *tParameters, lines bool) (tFormat, error) {
+	var err error
+	format := new(tJSONFormat)
+	format.lines = lines
+	format.maxDepth, err = interpretDepth(params, jsonDEPTH_DEFAULT, err)
+	format.fanout, err = interpretFanout(params, jsonFANOUT_DEFAULT, err)
+	if err == nil && params.schema.Available() {
+		format.schema, err = readSchema(params.schema.Values[0], nil)
+	}
+	if err == nil {
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+// sequential returns true, if records are numbered by a column of type
+// seq.
+func (format *tJSONFormat) sequential() bool {
+	return format.schema != nil && format.schema.sequential()
+}
+
+func (format *tJSONFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if !format.lines {
+		dst = append(dst, '[')
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+func (format *tJSONFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = format.appendRecord(dst, generator)
+	if !format.lines {
+		dst = append(dst, ',')
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tJSONFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	offset := len(dst)
+	dst = format.appendClosing(format.appendRecord(dst, generator), newLine)
+	limit := len(generator.bytes)
+	if generator.first() {
+		limit -= len(format.head(nil, generator, newLine))
+	}
+	if len(dst)-offset > limit {
+		// output is too small, so take an empty object, that is stretched
+		// by whitespace
+		generator.markStretch(offset+1, randomFillSpaces)
+		dst = format.appendClosing(append(dst[:offset], '{', '}'), newLine)
+	}
+	return dst
+}
+
+func (format *tJSONFormat) appendClosing(dst, newLine []byte) []byte {
+	dst = append(dst, newLine...)
+	if !format.lines {
+		dst = append(dst, ']')
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+func (format *tJSONFormat) appendRecord(dst []byte, generator *tGenerator) []byte {
+	if format.schema != nil {
+		dst = append(dst, '{')
+		for i, column := range format.schema.Columns {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, []byte(column.Name))
+			dst = append(dst, ':')
+			dst = appendJSONColumn(dst, column, generator)
+		}
+		return append(dst, '}')
+	}
+	return format.appendObject(dst, generator, 1)
+}
+
+func (format *tJSONFormat) appendObject(dst []byte, generator *tGenerator, depth int) []byte {
+	dst = append(dst, '{')
+	members := generator.random.Intn(format.fanout + 1)
+	offsets := make([]int, 0, members)
+	for i := 0; i < members; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		offsets = append(offsets, len(dst))
+		dst = appendJSONKey(dst, generator, offsets[:i])
+		// keys are lower case, so upper case letters keep them unique
+		generator.markStretch(len(dst)-1, randomFillU)
+		dst = append(dst, ':')
+		dst = format.appendValue(dst, generator, depth)
+	}
+	return append(dst, '}')
+}
+
+func (format *tJSONFormat) appendArray(dst []byte, generator *tGenerator, depth int) []byte {
+	dst = append(dst, '[')
+	elements := generator.random.Intn(format.fanout + 1)
+	for i := 0; i < elements; i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = format.appendValue(dst, generator, depth)
+	}
+	return append(dst, ']')
+}
+
+func (format *tJSONFormat) appendValue(dst []byte, generator *tGenerator, depth int) []byte {
+	kinds := 6
+	if depth < format.maxDepth {
+		kinds = 8
+	}
+	switch generator.random.Intn(kinds) {
+	case 0, 1:
+		generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(4))
+		dst = appendJSONString(dst, generator.scratch)
+		generator.markStretch(len(dst)-1, generator.wordFill())
+		return dst
+	case 2:
+		return strconv.AppendInt(dst, generator.random.Int63n(2000000001)-1000000000, 10)
+	case 3:
+		return strconv.AppendFloat(dst, (generator.random.Float64()-0.5)*1e6, 'g', -1, 64)
+	case 4:
+		return strconv.AppendBool(dst, generator.random.Intn(2) == 0)
+	case 5:
+		return append(dst, "null"...)
+	case 6:
+		return format.appendObject(dst, generator, depth+1)
+	}
+	return format.appendArray(dst, generator, depth+1)
+}
+
+// appendJSONKey appends a key, that is not at offsets.
+func appendJSONKey(dst []byte, generator *tGenerator, offsets []int) []byte {
+	offset := len(dst)
+	for {
+		dst = append(dst, '"')
+		dst = generator.appendName(dst, 3, 12)
+		dst = append(dst, '"')
+		if !containsKey(dst, offset, offsets) {
+			return dst
+		}
+		dst = dst[:offset]
+	}
+}
+
+func containsKey(dst []byte, offset int, offsets []int) bool {
+	key := dst[offset:]
+	for _, offsetOther := range offsets {
+		if bytes.HasPrefix(dst[offsetOther:], key) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendJSONColumn(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	if column.isNull(generator) {
+		return append(dst, "null"...)
+	}
+	if column.isNumeric() {
+		return column.appendValue(dst, generator, []byte{'\n'})
+	}
+	generator.scratch = column.appendValue(generator.scratch[:0], generator, []byte{'\n'})
+	dst = appendJSONString(dst, generator.scratch)
+	if stretch, fill := column.stretchOffset(generator.scratch, generator); stretch == len(generator.scratch) {
+		generator.markStretch(len(dst)-1, fill)
+	}
+	return dst
+}
+
+// appendJSONString appends value as quoted and escaped JSON string.
+func appendJSONString(dst, value []byte) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for _, b := range value {
+		switch b {
+		case '"', '\\':
+			dst = append(dst, '\\', b)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if b < 0x20 {
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0x0f])
+			} else {
+				dst = append(dst, b)
+			}
+		}
+	}
+	return append(dst, '"')
+}
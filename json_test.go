@@ -0,0 +1,40 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONValid(t *testing.T) {
+	for _, size := range []int{6, 100, 5000} {
+		format := new(tJSONFormat)
+		format.maxDepth = 3
+		format.fanout = 4
+		output := generateTest(newRecords(format), size, 1000, []byte{'\n'})
+		if len(output) != size || !json.Valid(output) {
+			t.Error("invalid JSON:", string(output))
+		}
+		format.lines = true
+		output = generateTest(newRecords(format), size, 1000, []byte{'\n'})
+		for _, line := range bytes.Split(output, []byte{'\n'}) {
+			if len(bytes.TrimSpace(line)) > 0 && !json.Valid(line) {
+				t.Error("invalid JSON line:", string(line))
+			}
+		}
+	}
+}
+
+func TestJSONString(t *testing.T) {
+	str := string(appendJSONString(nil, []byte("a\"\\\n\x01")))
+	if str != "\"a\\\"\\\\\\n\\u0001\"" {
+		t.Error("wrong escaping:", str)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+const localeDEFAULT = "en"
+
+// tLocale holds word lists and formats of fake personal data. In formats
+// '#' is a digit and 'A' an upper case letter.
+type tLocale struct {
+	firstNames []string
+	lastNames  []string
+	streets    []string
+	cities     []string
+	address    string
+	postcode   string
+	phone      string
+	iban       string
+	domain     string
+}
+
+var locales = map[string]*tLocale{
+	"en": {
+		firstNames: []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth", "David", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Charles", "Karen", "Daniel", "Nancy", "Matthew", "Lisa", "Anthony", "Betty", "Mark", "Margaret", "Steven", "Emily", "Andrew", "Olivia"},
+		lastNames:  []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez", "Hernandez", "Lopez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin", "Lee", "Thompson", "White", "Harris", "Clark", "Lewis", "Robinson", "Walker", "Young", "Allen", "King", "Wright", "O'Connor"},
+		streets:    []string{"Main Street", "Oak Avenue", "Maple Drive", "Cedar Lane", "Park Road", "Elm Street", "Washington Avenue", "Lake Shore Drive", "Hill Road", "Pine Street", "Sunset Boulevard", "Church Lane", "Highland Avenue", "River Road", "Mill Street", "Forest Drive"},
+		cities:     []string{"Springfield", "Riverside", "Franklin", "Greenville", "Bristol", "Clinton", "Fairview", "Salem", "Madison", "Georgetown", "Arlington", "Ashland", "Dover", "Oxford", "Jackson", "Burlington"},
+		address:    "{number} {street}, {city}, {postcode}",
+		postcode:   "#####",
+		phone:      "+1 ###-###-####",
+		iban:       "GBAAAA##############",
+		domain:     "example.com"},
+	"de": {
+		firstNames: []string{"Maximilian", "Sophie", "Alexander", "Marie", "Paul", "Emilia", "Elias", "Hannah", "Lukas", "Lena", "Jonas", "Mia", "Leon", "Lea", "Finn", "Anna", "Noah", "Johanna", "Felix", "Clara", "Jürgen", "Jörg", "Günther", "Käthe", "Björn", "Ursula", "Matthias", "Sabine", "Stefan", "Petra", "Wolfgang", "Brigitte"},
+		lastNames:  []string{"Müller", "Schmidt", "Schneider", "Fischer", "Weber", "Meyer", "Wagner", "Becker", "Schulz", "Hoffmann", "Schäfer", "Koch", "Bauer", "Richter", "Klein", "Wolf", "Schröder", "Neumann", "Schwarz", "Zimmermann", "Braun", "Krüger", "Hofmann", "Hartmann", "Lange", "Schmitt", "Werner", "Schmitz", "Krause", "Meier", "Lehmann", "Köhler"},
+		streets:    []string{"Hauptstraße", "Schulstraße", "Gartenstraße", "Bahnhofstraße", "Dorfstraße", "Bergstraße", "Birkenweg", "Lindenstraße", "Kirchstraße", "Waldstraße", "Ringstraße", "Am Markt", "Goethestraße", "Schillerstraße", "Mühlenweg", "Rosenweg"},
+		cities:     []string{"Berlin", "Hamburg", "München", "Köln", "Frankfurt am Main", "Stuttgart", "Düsseldorf", "Leipzig", "Dortmund", "Essen", "Bremen", "Dresden", "Hannover", "Nürnberg", "Duisburg", "Bochum"},
+		address:    "{street} {number}, {postcode} {city}",
+		postcode:   "#####",
+		phone:      "+49 ### #######",
+		iban:       "DE##################",
+		domain:     "example.de"},
+	"fr": {
+		firstNames: []string{"Gabriel", "Louise", "Léo", "Jade", "Raphaël", "Ambre", "Arthur", "Alice", "Louis", "Emma", "Jules", "Rose", "Adam", "Chloé", "Maël", "Léa", "Lucas", "Anna", "Hugo", "Mila", "Noé", "Inès", "Théo", "Zoé", "François", "Hélène", "Jérôme", "Cécile", "Benoît", "Élodie", "Stéphane", "Margaux"},
+		lastNames:  []string{"Martin", "Bernard", "Thomas", "Petit", "Robert", "Richard", "Durand", "Dubois", "Moreau", "Laurent", "Simon", "Michel", "Lefèvre", "Leroy", "Roux", "David", "Bertrand", "Morel", "Fournier", "Girard", "Bonnet", "Dupont", "Lambert", "Fontaine", "Rousseau", "Vincent", "Müller", "Lefebvre", "Faure", "André", "Mercier", "Blanc"},
+		streets:    []string{"rue de la Paix", "rue Victor Hugo", "avenue des Champs-Élysées", "boulevard Saint-Michel", "rue de la République", "place de la Mairie", "rue du Moulin", "rue de l'Église", "chemin des Vignes", "allée des Tilleuls", "rue Pasteur", "avenue Jean Jaurès", "rue des Écoles", "impasse des Lilas", "quai de la Loire", "rue Nationale"},
+		cities:     []string{"Paris", "Marseille", "Lyon", "Toulouse", "Nice", "Nantes", "Strasbourg", "Montpellier", "Bordeaux", "Lille", "Rennes", "Reims", "Saint-Étienne", "Le Havre", "Toulon", "Grenoble"},
+		address:    "{number} {street}, {postcode} {city}",
+		postcode:   "#####",
+		phone:      "+33 # ## ## ## ##",
+		iban:       "FR#######################",
+		domain:     "example.fr"},
+}
+
+// asciiReplacements maps letters of word lists, that are not allowed in
+// email addresses.
+var asciiReplacements = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss", "à", "a", "â", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e", "É", "e", "î", "i", "ï", "i", "ô", "o", "û", "u", "ù", "u", "'", "", " ", "-")
+
+func interpretLocale(params *tParameters, err error) (*tLocale, error) {
+	if err == nil {
+		name := localeDEFAULT
+		if params.locale.Available() {
+			name = params.locale.Values[0]
+		}
+		return findLocale(name)
+	}
+	return nil, err
+}
+
+func findLocale(name string) (*tLocale, error) {
+	locale := locales[strings.ToLower(name)]
+	if locale == nil {
+		names := make([]string, 0, len(locales))
+		for nameOther := range locales {
+			names = append(names, nameOther)
+		}
+		sort.Strings(names)
+		return nil, errors.New("unknown locale \"" + name + "\" (expected " + strings.Join(names, ", ") + ")")
+	}
+	return locale, nil
+}
@@ -0,0 +1,276 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	logCOMBINED = iota
+	logRFC3164
+	logRFC5424
+	logLOGFMT
+	logJSON
+)
+
+const (
+	logRATE_DEFAULT = 100
+	logSAMPLES      = 64
+	logHOSTS        = 8
+	logAPPS         = 6
+)
+
+var logPresets = []string{"combined", "syslog", "rfc5424", "logfmt", "json"}
+var logLevels = []string{"debug", "info", "warn", "error"}
+var logLevelWeights = []float64{2, 12, 3, 1}
+var logSeverities = []int{7, 6, 4, 3}
+var logMethods = []string{"GET", "GET", "GET", "GET", "POST", "POST", "PUT", "DELETE", "HEAD"}
+var logStatus = []int{200, 200, 200, 200, 200, 200, 201, 204, 301, 302, 304, 400, 401, 403, 404, 404, 500, 502, 503}
+var logAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/104.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:104.0) Gecko/20100101 Firefox/104.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 15_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.6 Mobile/15E148 Safari/604.1",
+	"curl/7.84.0",
+	"Go-http-client/1.1",
+	"Googlebot/2.1 (+http://www.google.com/bot.html)"}
+
+// tLogFormat generates log lines. Timestamps depend on position of line
+// in output, i.e. they increase monotonically at the rate of events per
+// second, regardless of buffers and threads.
+type tLogFormat struct {
+	preset    int
+	start     time.Time
+	rate      float64
+	lengthAvg float64
+	hosts     []string
+	apps      []string
+}
+
+func interpretLog(params *tParameters) (tFormat, error) {
+	var err error
+	format := new(tLogFormat)
+	format.preset, err = interpretPreset(params, err)
+	format.start, err = interpretTime(params, err)
+	format.rate, err = interpretEventRate(params, logRATE_DEFAULT, err)
+	if err == nil {
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+// initLog initializes pools of log format with seed and alphabet of
+// content, i.e. of output.
+func initLog(content *tContent, err error) error {
+	if records, ok := content.format.(*tRecords); ok && err == nil {
+		if format, ok := records.format.(*tLogFormat); ok {
+			format.initPools(content)
+		}
+	}
+	return err
+}
+
+func interpretPreset(params *tParameters, err error) (int, error) {
+	if err == nil && params.preset.Available() {
+		preset := indexOf(logPresets, strings.ToLower(params.preset.Values[0]))
+		if preset < 0 {
+			return 0, errors.New("unknown log preset \"" + params.preset.Values[0] + "\" (expected " + strings.Join(logPresets, ", ") + ")")
+		}
+		return preset, nil
+	}
+	return logCOMBINED, err
+}
+
+func interpretTime(params *tParameters, err error) (time.Time, error) {
+	if err == nil {
+		if params.time.Available() {
+			start, err := time.Parse(time.RFC3339, params.time.Values[0])
+			if err != nil {
+				start, err = time.Parse(dateLAYOUT, params.time.Values[0])
+			}
+			if err == nil {
+				return start, nil
+			}
+			return start, errors.New("can't parse time (expected e.g. 2022-01-01 or 2022-01-01T12:00:00Z)")
+		}
+		return time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, err
+}
+
+func interpretEventRate(params *tParameters, rateDefault float64, err error) (float64, error) {
+	if err == nil {
+		if params.rate.Available() {
+			rate, err := strconv.ParseFloat(params.rate.Values[0], 64)
+			if err == nil && rate > 0 {
+				return rate, nil
+			}
+			return 0, errors.New("can't parse rate")
+		}
+		return rateDefault, nil
+	}
+	return 0, err
+}
+
+// initPools generates names of hosts and applications and measures the
+// average length of lines.
+func (format *tLogFormat) initPools(content *tContent) {
+	var length int
+	generator := newGenerator(0, content)
+	for _, name := range generator.uniqueNames(logHOSTS) {
+		format.hosts = append(format.hosts, name+"-"+strconv.Itoa(1+generator.random.Intn(99)))
+	}
+	format.apps = generator.uniqueNames(logAPPS)
+	format.lengthAvg = 1
+	for i := 0; i < logSAMPLES; i++ {
+		length += len(format.record(nil, generator, []byte{'\n'}))
+	}
+	format.lengthAvg = float64(length) / logSAMPLES
+}
+
+func (format *tLogFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func (format *tLogFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	seconds := float64(generator.position()) / format.lengthAvg / format.rate
+	timestamp := format.start.Add(time.Duration(seconds * float64(time.Second)))
+	level := chooseWeighted(logLevelWeights, generator)
+	host := format.hosts[generator.random.Intn(len(format.hosts))]
+	switch format.preset {
+	case logCOMBINED:
+		dst = format.appendCombined(dst, generator, timestamp)
+	case logRFC3164:
+		dst = append(dst, '<')
+		dst = strconv.AppendInt(dst, int64(8+logSeverities[level]), 10)
+		dst = append(dst, '>')
+		dst = timestamp.AppendFormat(dst, time.Stamp)
+		dst = append(dst, ' ')
+		dst = append(dst, host...)
+		dst = append(dst, ' ')
+		dst = append(dst, format.apps[generator.random.Intn(len(format.apps))]...)
+		dst = append(dst, '[')
+		dst = strconv.AppendInt(dst, 100+generator.random.Int63n(32668), 10)
+		dst = append(dst, "]: "...)
+		dst = generator.appendWords(dst, 2+generator.random.Intn(12))
+		generator.markStretch(len(dst), generator.wordFill())
+	case logRFC5424:
+		dst = append(dst, '<')
+		dst = strconv.AppendInt(dst, int64(8*(16+generator.random.Intn(8))+logSeverities[level]), 10)
+		dst = append(dst, ">1 "...)
+		dst = timestamp.AppendFormat(dst, "2006-01-02T15:04:05.000000Z07:00")
+		dst = append(dst, ' ')
+		dst = append(dst, host...)
+		dst = append(dst, ' ')
+		dst = append(dst, format.apps[generator.random.Intn(len(format.apps))]...)
+		dst = append(dst, ' ')
+		dst = strconv.AppendInt(dst, 100+generator.random.Int63n(32668), 10)
+		dst = append(dst, " ID"...)
+		dst = strconv.AppendInt(dst, generator.random.Int63n(100), 10)
+		if generator.random.Intn(2) == 0 {
+			dst = append(dst, " - "...)
+		} else {
+			dst = append(dst, " [meta@32473 seq=\""...)
+			dst = strconv.AppendInt(dst, int64(generator.position()), 10)
+			dst = append(dst, "\"] "...)
+		}
+		dst = generator.appendWords(dst, 2+generator.random.Intn(12))
+		generator.markStretch(len(dst), generator.wordFill())
+	case logLOGFMT:
+		dst = append(dst, "time="...)
+		dst = timestamp.AppendFormat(dst, "2006-01-02T15:04:05.000Z07:00")
+		dst = append(dst, " level="...)
+		dst = append(dst, logLevels[level]...)
+		dst = append(dst, " host="...)
+		dst = append(dst, host...)
+		dst = append(dst, " msg="...)
+		generator.scratch = generator.appendWords(generator.scratch[:0], 2+generator.random.Intn(12))
+		dst = appendJSONString(dst, generator.scratch)
+		dst = append(dst, " path="...)
+		dst = format.appendPath(dst, generator)
+		dst = append(dst, " duration="...)
+		dst = strconv.AppendInt(dst, 1+generator.random.Int63n(2000), 10)
+		dst = append(dst, "ms"...)
+	case logJSON:
+		dst = append(dst, "{\"time\":\""...)
+		dst = timestamp.AppendFormat(dst, "2006-01-02T15:04:05.000Z07:00")
+		dst = append(dst, "\",\"level\":\""...)
+		dst = append(dst, logLevels[level]...)
+		dst = append(dst, "\",\"host\":\""...)
+		dst = append(dst, host...)
+		dst = append(dst, "\",\"msg\":"...)
+		generator.scratch = generator.appendWords(generator.scratch[:0], 2+generator.random.Intn(12))
+		dst = appendJSONString(dst, generator.scratch)
+		dst = append(dst, ",\"path\":\""...)
+		dst = format.appendPath(dst, generator)
+		dst = append(dst, "\",\"duration_ms\":"...)
+		dst = strconv.AppendInt(dst, 1+generator.random.Int63n(2000), 10)
+		dst = append(dst, '}')
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tLogFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// appendCombined appends a line of Apache/Nginx combined log format.
+func (format *tLogFormat) appendCombined(dst []byte, generator *tGenerator, timestamp time.Time) []byte {
+	for i := 0; i < 4; i++ {
+		if i > 0 {
+			dst = append(dst, '.')
+		}
+		dst = strconv.AppendInt(dst, 1+generator.random.Int63n(254), 10)
+	}
+	if generator.random.Intn(8) == 0 {
+		dst = append(dst, " - "...)
+		dst = generator.appendName(dst, 3, 10)
+		dst = append(dst, " ["...)
+	} else {
+		dst = append(dst, " - - ["...)
+	}
+	dst = timestamp.AppendFormat(dst, "02/Jan/2006:15:04:05 -0700")
+	dst = append(dst, "] \""...)
+	dst = append(dst, logMethods[generator.random.Intn(len(logMethods))]...)
+	dst = append(dst, ' ')
+	dst = format.appendPath(dst, generator)
+	dst = append(dst, " HTTP/1.1\" "...)
+	dst = strconv.AppendInt(dst, int64(logStatus[generator.random.Intn(len(logStatus))]), 10)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, generator.random.Int63n(100000), 10)
+	if generator.random.Intn(3) == 0 {
+		dst = append(dst, " \"-\" \""...)
+	} else {
+		dst = append(dst, " \"https://"...)
+		dst = append(dst, format.hosts[generator.random.Intn(len(format.hosts))]...)
+		dst = format.appendPath(dst, generator)
+		dst = append(dst, "\" \""...)
+	}
+	dst = append(dst, logAgents[generator.random.Intn(len(logAgents))]...)
+	return append(dst, '"')
+}
+
+// appendPath appends a request path, sometimes with query. Record is
+// stretched by the path.
+func (format *tLogFormat) appendPath(dst []byte, generator *tGenerator) []byte {
+	for i := generator.random.Intn(4); i >= 0; i-- {
+		dst = append(dst, '/')
+		dst = generator.appendName(dst, 2, 10)
+		generator.markStretch(len(dst), randomFillL)
+	}
+	if generator.random.Intn(4) == 0 {
+		dst = append(dst, '?')
+		dst = generator.appendName(dst, 1, 6)
+		dst = append(dst, '=')
+		dst = strconv.AppendInt(dst, generator.random.Int63n(10000), 10)
+	}
+	return dst
+}
@@ -0,0 +1,44 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestLogTimestamps(t *testing.T) {
+	format := new(tLogFormat)
+	format.preset = logJSON
+	format.start = time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	format.rate = 10
+	format.initPools(&tContent{seed: 1, randomFill: randomFillZ})
+	output := generateTest(newRecords(format), 100000, 3000, []byte{'\n'})
+	var lines int
+	var timeLast time.Time
+	for _, line := range bytes.Split(output, []byte{'\n'}) {
+		var entry struct{ Time time.Time }
+		if len(bytes.TrimSpace(line)) > 0 {
+			err := json.Unmarshal(line, &entry)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if entry.Time.Before(timeLast) {
+				t.Error("time decreases:", entry.Time, timeLast)
+			}
+			timeLast = entry.Time
+			lines++
+		}
+	}
+	seconds := timeLast.Sub(format.start).Seconds()
+	if seconds < float64(lines)/12 || seconds > float64(lines)/8 {
+		t.Error("wrong rate:", lines, seconds)
+	}
+}
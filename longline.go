@@ -0,0 +1,127 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const longUNITS = 256
+
+// tLongLines writes lines of random words of exact length, each followed
+// by a random number of empty lines. The numbers of empty lines repeat a
+// pattern, so positions of new lines are known without generating previous
+// lines. Length 0 is one line without new line.
+type tLongLines struct {
+	length int
+	empty  []int
+	starts []int
+	period int
+}
+
+func interpretLongLines(params *tParameters, content *tContent, newLine []byte, err error) (tFormat, error) {
+	if err == nil && params.lineLength.Available() {
+		if _, ok := content.format.(*tText); !ok {
+			return nil, errors.New("line-length needs text output")
+		}
+		format := new(tLongLines)
+		format.length, err = parseBytes(params.lineLength.Values[0])
+		if err != nil || format.length < 0 {
+			return nil, errors.New("can't parse line length")
+		}
+		var rate float64
+		rate, err = interpretRate(params.emptyLines, "empty-lines", err)
+		if err == nil && rate >= 1 {
+			return nil, errors.New("can't parse empty-lines rate (expected less than 1)")
+		} else if err == nil && rate > 0 && format.length == 0 {
+			return nil, errors.New("empty-lines needs line-length greater than 0")
+		}
+		if err == nil {
+			format.init(content.seed, rate, newLine)
+			return format, nil
+		}
+		return nil, err
+	} else if err == nil && params.emptyLines.Available() {
+		return nil, errors.New("empty-lines needs line-length")
+	}
+	return content.format, err
+}
+
+// init sets the pattern of empty lines. Their number after a line is
+// geometrically distributed, so that rate of all lines are empty.
+func (format *tLongLines) init(seed int64, rate float64, newLine []byte) {
+	random := rand.New(rand.NewSource(seed))
+	format.empty = make([]int, longUNITS)
+	format.starts = make([]int, longUNITS)
+	format.period = 0
+	for i := range format.empty {
+		if rate > 0 {
+			format.empty[i] = int(math.Log(1-random.Float64()) / math.Log(rate))
+		}
+		format.starts[i] = format.period
+		format.period += format.length + len(newLine)*(1+format.empty[i])
+	}
+}
+
+func (format *tLongLines) fill(generator *tGenerator, newLine []byte) {
+	if format.length == 0 {
+		generator.fillLine(generator.bytes, generator.last())
+	} else {
+		offset, end := generator.offset, generator.offset+len(generator.bytes)
+		index := sort.SearchInts(format.starts, offset%format.period+1) - 1
+		start := offset - offset%format.period + format.starts[index]
+		for start < end {
+			lineEnd := start + format.length
+			if lineEnd > offset {
+				from, to := maxInt(start, offset), minInt(lineEnd, end)
+				generator.fillLine(generator.bytes[from-offset:to-offset], to == lineEnd)
+			}
+			next := lineEnd + len(newLine)*(1+format.empty[index])
+			for position := maxInt(lineEnd, offset); position < minInt(next, end); position++ {
+				generator.bytes[position-offset] = newLine[(position-lineEnd)%len(newLine)]
+			}
+			start, index = next, (index+1)%len(format.empty)
+		}
+	}
+	generator.written = len(generator.bytes)
+}
+
+// fillLine fills line with random words. Line doesn't end with space, if
+// last is true.
+func (generator *tGenerator) fillLine(line []byte, last bool) {
+	written := 0
+	for written < len(line) {
+		lengthWord := generator.randWordLength(len(line) - written)
+		generator.randomFill(generator.random, line[written:written+lengthWord])
+		written += lengthWord
+		if written < len(line) {
+			line[written] = ' '
+			written++
+		}
+	}
+	if last && len(line) > 0 && line[len(line)-1] == ' ' {
+		generator.randomFill(generator.random, line[len(line)-1:])
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
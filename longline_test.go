@@ -0,0 +1,44 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestLongLines(t *testing.T) {
+	newLine := []byte{'\r', '\n'}
+	format := &tLongLines{length: 5000}
+	format.init(1, 0.8, newLine)
+	output := generateTest(format, 1<<22, 1000, newLine)
+	lines := bytes.Split(output, newLine)
+	var empty int
+	for i, line := range lines[:len(lines)-1] {
+		if len(line) == 0 {
+			empty++
+		} else if len(line) != 5000 {
+			t.Fatal("wrong length of line", i, len(line))
+		} else if line[0] == ' ' || line[len(line)-1] == ' ' || bytes.Contains(line, []byte("  ")) {
+			t.Fatal("wrong spaces in line", i)
+		}
+	}
+	if rate := float64(empty) / float64(len(lines)-1); math.Abs(rate-0.8) > 0.02 {
+		t.Error("wrong rate of empty lines:", rate)
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	format := &tLongLines{}
+	format.init(1, 0, []byte{'\n'})
+	output := generateTest(format, 100000, 999, []byte{'\n'})
+	if len(output) != 100000 || bytes.IndexByte(output, '\n') >= 0 {
+		t.Error("output is not one line")
+	}
+}
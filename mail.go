@@ -0,0 +1,423 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+const (
+	mailRATE_DEFAULT   = 0.01
+	mailSAMPLES        = 16
+	mailTHREADS        = 32
+	mailREFERENCES_MAX = 10
+	mailLINE_MAX       = 76
+	mailATTACHMENT_MAX = 16 * 1024
+)
+
+var mailAttachments = []string{"txt", "csv", "log", "dat"}
+var mailAttachmentTypes = []string{"text/plain", "text/csv", "text/plain", "application/octet-stream"}
+
+// tMailFormat generates RFC 5322 messages. Records of mbox are messages,
+// records of eml are parts of one multipart message. Dates depend on
+// position of message in output, like timestamps of logs.
+type tMailFormat struct {
+	mbox      bool
+	start     time.Time
+	rate      float64
+	lengthAvg float64
+	boundary  string
+}
+
+// tMailState holds recent messages of buffer, that are replied to. The
+// message of the last record is pending, until the record is written, i.e.
+// the number of records exceeds records.
+type tMailState struct {
+	threads []tMailThread
+	pending tMailThread
+	records int64
+}
+
+type tMailThread struct {
+	id         []byte
+	subject    []byte
+	references []byte
+}
+
+func interpretMail(params *tParameters, mbox bool) (tFormat, error) {
+	var err error
+	format := new(tMailFormat)
+	format.mbox = mbox
+	format.start, err = interpretTime(params, err)
+	format.rate, err = interpretEventRate(params, mailRATE_DEFAULT, err)
+	if err == nil {
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+// initMail initializes samples of mail format with seed, alphabet and
+// locale of content, i.e. of output.
+func initMail(content *tContent, err error) error {
+	if records, ok := content.format.(*tRecords); ok && err == nil {
+		if format, ok := records.format.(*tMailFormat); ok {
+			format.initSamples(content)
+		}
+	}
+	return err
+}
+
+// initSamples generates the boundary of eml and measures the average
+// length of messages.
+func (format *tMailFormat) initSamples(content *tContent) {
+	var length int
+	generator := newGenerator(0, content)
+	format.boundary = string(generator.appendRandom([]byte("=_"), 24, randomFillA))
+	format.lengthAvg = 1
+	if format.mbox {
+		for i := 0; i < mailSAMPLES; i++ {
+			length += len(format.record(nil, generator, []byte{'\n'}))
+		}
+		format.lengthAvg = float64(length) / mailSAMPLES
+	}
+}
+
+func (format *tMailFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if !format.mbox {
+		from := appendMailbox(nil, generator, generator.locale())
+		dst = format.appendHeaders(dst, generator, newLine, format.start, from)
+		dst = appendMultipart(dst, newLine, format.boundary)
+	}
+	return dst
+}
+
+func (format *tMailFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.mbox {
+		return format.appendMessage(dst, generator, newLine)
+	}
+	return format.appendPart(dst, generator, newLine, format.boundary, generator.random.Intn(3) == 0)
+}
+
+func (format *tMailFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if !format.mbox {
+		dst = append(dst, "--"...)
+		dst = append(dst, format.boundary...)
+		dst = append(dst, "--"...)
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+// appendMessage appends a message of mbox, i.e. with "From " line and
+// blank line at the end.
+func (format *tMailFormat) appendMessage(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	seconds := float64(generator.position()) / format.lengthAvg / format.rate
+	date := format.start.Add(time.Duration(seconds * float64(time.Second)))
+	from := appendMailbox(nil, generator, generator.locale())
+	dst = append(dst, "From "...)
+	dst = append(dst, from[bytes.IndexByte(from, '<')+1:len(from)-1]...)
+	dst = append(dst, ' ')
+	dst = date.UTC().AppendFormat(dst, time.ANSIC)
+	dst = append(dst, newLine...)
+	dst = format.appendHeaders(dst, generator, newLine, date, from)
+	if generator.random.Intn(3) == 0 {
+		dst = appendTextHeaders(dst, newLine)
+		dst = append(dst, newLine...)
+		dst = appendText(dst, generator, newLine)
+	} else {
+		boundary := "=_" + strconv.Itoa(generator.position())
+		dst = appendMultipart(dst, newLine, boundary)
+		dst = format.appendPart(dst, generator, newLine, boundary, true)
+		for i := generator.random.Intn(3); i > 0; i-- {
+			dst = format.appendPart(dst, generator, newLine, boundary, false)
+		}
+		dst = append(dst, "--"...)
+		dst = append(dst, boundary...)
+		dst = append(dst, "--"...)
+		dst = append(dst, newLine...)
+	}
+	return append(dst, newLine...)
+}
+
+// appendHeaders appends headers of message. Some messages are replies to
+// recent messages of buffer, i.e. have In-Reply-To and References. Messages
+// of discarded records are never replied to.
+func (format *tMailFormat) appendHeaders(dst []byte, generator *tGenerator, newLine []byte, date time.Time, from []byte) []byte {
+	state, ok := generator.state.(*tMailState)
+	if !ok {
+		state = new(tMailState)
+		generator.state = state
+	} else if len(state.pending.id) > 0 && generator.records > state.records {
+		state.register(state.pending, generator)
+	}
+	locale := generator.locale()
+	thread := tMailThread{id: appendMessageID(nil, generator, locale)}
+	dst = append(dst, "Date: "...)
+	dst = date.AppendFormat(dst, time.RFC1123Z)
+	dst = append(dst, newLine...)
+	dst = append(dst, "From: "...)
+	dst = append(dst, from...)
+	dst = append(dst, newLine...)
+	dst = append(dst, "To: "...)
+	for i := generator.random.Intn(3); i >= 0; i-- {
+		dst = appendMailbox(dst, generator, locale)
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+	}
+	dst = append(dst, newLine...)
+	if len(state.threads) > 0 && generator.random.Intn(3) == 0 {
+		parent := state.threads[generator.random.Intn(len(state.threads))]
+		thread.subject = append(thread.subject, parent.subject...)
+		thread.references = append(thread.references, parent.references...)
+		if len(thread.references) > 0 {
+			thread.references = append(thread.references, ' ')
+		}
+		thread.references = append(thread.references, parent.id...)
+		for bytes.Count(thread.references, []byte{' '}) >= mailREFERENCES_MAX {
+			thread.references = thread.references[bytes.IndexByte(thread.references, ' ')+1:]
+		}
+		dst = append(dst, "Subject: Re: "...)
+		dst = append(dst, thread.subject...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "Message-ID: "...)
+		dst = append(dst, thread.id...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "In-Reply-To: "...)
+		dst = append(dst, parent.id...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "References: "...)
+		dst = append(dst, thread.references...)
+		dst = append(dst, newLine...)
+	} else {
+		thread.subject = generator.appendWords(nil, 1+generator.random.Intn(8))
+		dst = append(dst, "Subject: "...)
+		dst = append(dst, thread.subject...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "Message-ID: "...)
+		dst = append(dst, thread.id...)
+		dst = append(dst, newLine...)
+	}
+	state.pending, state.records = thread, generator.records
+	dst = append(dst, "MIME-Version: 1.0"...)
+	return append(dst, newLine...)
+}
+
+// register adds the message of a written record to the threads, that are
+// replied to.
+func (state *tMailState) register(thread tMailThread, generator *tGenerator) {
+	if len(state.threads) < mailTHREADS {
+		state.threads = append(state.threads, thread)
+	} else {
+		state.threads[generator.random.Intn(mailTHREADS)] = thread
+	}
+}
+
+// appendMessageID appends a message ID, that is unique by position in
+// output.
+func appendMessageID(dst []byte, generator *tGenerator, locale *tLocale) []byte {
+	dst = append(dst, '<')
+	dst = strconv.AppendInt(dst, int64(generator.position()), 10)
+	dst = append(dst, '.')
+	dst = generator.appendRandom(dst, 12, randomFillA)
+	dst = append(dst, '@')
+	dst = append(dst, locale.domain...)
+	return append(dst, '>')
+}
+
+// appendMailbox appends name and address, e.g. "Jane Doe <jane.doe@example.com>".
+// Names with non-ASCII characters are encoded words (RFC 2047).
+func appendMailbox(dst []byte, generator *tGenerator, locale *tLocale) []byte {
+	first := locale.firstNames[generator.random.Intn(len(locale.firstNames))]
+	last := locale.lastNames[generator.random.Intn(len(locale.lastNames))]
+	name := first + " " + last
+	if isASCII(name) {
+		dst = append(dst, name...)
+	} else {
+		dst = append(dst, "=?UTF-8?Q?"...)
+		for i := 0; i < len(name); i++ {
+			b := name[i]
+			if b == ' ' {
+				dst = append(dst, '_')
+			} else if b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' {
+				dst = append(dst, b)
+			} else {
+				dst = appendHexEscape(dst, b)
+			}
+		}
+		dst = append(dst, "?="...)
+	}
+	dst = append(dst, " <"...)
+	dst = append(dst, toASCII(first)...)
+	dst = append(dst, '.')
+	dst = append(dst, toASCII(last)...)
+	dst = append(dst, '@')
+	dst = append(dst, locale.domain...)
+	return append(dst, '>')
+}
+
+func appendMultipart(dst, newLine []byte, boundary string) []byte {
+	dst = append(dst, "Content-Type: multipart/mixed; boundary=\""...)
+	dst = append(dst, boundary...)
+	dst = append(dst, '"')
+	dst = append(dst, newLine...)
+	dst = append(dst, newLine...)
+	dst = append(dst, "This is a multi-part message in MIME format."...)
+	return append(dst, newLine...)
+}
+
+func appendTextHeaders(dst, newLine []byte) []byte {
+	dst = append(dst, "Content-Type: text/plain; charset=utf-8"...)
+	dst = append(dst, newLine...)
+	dst = append(dst, "Content-Transfer-Encoding: quoted-printable"...)
+	return append(dst, newLine...)
+}
+
+// appendPart appends delimiter and body part, i.e. text or attachment.
+func (format *tMailFormat) appendPart(dst []byte, generator *tGenerator, newLine []byte, boundary string, text bool) []byte {
+	dst = append(dst, newLine...)
+	dst = append(dst, "--"...)
+	dst = append(dst, boundary...)
+	dst = append(dst, newLine...)
+	if text {
+		dst = appendTextHeaders(dst, newLine)
+		dst = append(dst, newLine...)
+		return appendText(dst, generator, newLine)
+	}
+	kind := generator.random.Intn(len(mailAttachments))
+	name := generator.appendName(nil, 3, 12)
+	name = append(name, '.')
+	name = append(name, mailAttachments[kind]...)
+	dst = append(dst, "Content-Type: "...)
+	dst = append(dst, mailAttachmentTypes[kind]...)
+	dst = append(dst, "; name=\""...)
+	dst = append(dst, name...)
+	dst = append(dst, '"')
+	dst = append(dst, newLine...)
+	dst = append(dst, "Content-Disposition: attachment; filename=\""...)
+	dst = append(dst, name...)
+	dst = append(dst, '"')
+	dst = append(dst, newLine...)
+	dst = append(dst, "Content-Transfer-Encoding: base64"...)
+	dst = append(dst, newLine...)
+	dst = append(dst, newLine...)
+	generator.scratch = generator.scratch[:0]
+	for length := 1 + generator.random.Intn(mailATTACHMENT_MAX); len(generator.scratch) < length; {
+		generator.scratch = generator.appendWords(generator.scratch, 1+generator.random.Intn(12))
+		generator.scratch = append(generator.scratch, '\r', '\n')
+	}
+	return appendBase64(dst, generator.scratch, newLine)
+}
+
+// appendText appends paragraphs and signature as quoted-printable. Record
+// is stretched by the last line of paragraphs.
+func appendText(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	generator.scratch = generator.scratch[:0]
+	for i := generator.random.Intn(5); i >= 0; i-- {
+		for j := 1 + generator.random.Intn(6); j > 0; j-- {
+			generator.scratch = generator.appendWords(generator.scratch, 4+generator.random.Intn(12))
+			generator.scratch = append(generator.scratch, '\n')
+		}
+		generator.scratch = append(generator.scratch, '\n')
+	}
+	dst = appendQuotedPrintable(dst, generator.scratch, newLine)
+	end := len(dst) - 2*len(newLine)
+	length := end - bytes.LastIndex(dst[:end], newLine) - len(newLine)
+	generator.markStretch(end, fillQuotedPrintable(length, newLine, generator.wordFill()))
+	generator.scratch = append(generator.scratch[:0], "-- \n"...)
+	generator.scratch = appendFakeName(generator.scratch, generator.locale(), generator)
+	generator.scratch = append(generator.scratch, '\n')
+	return appendQuotedPrintable(dst, generator.scratch, newLine)
+}
+
+// fillQuotedPrintable returns a function, that fills bytes with letters of
+// fill continuing a quoted-printable line of length. Lines are broken by
+// soft line breaks, so they don't exceed the limit.
+func fillQuotedPrintable(length int, newLine []byte, fill func(*rand.Rand, []byte)) func(*rand.Rand, []byte) {
+	return func(random *rand.Rand, bytes []byte) {
+		fill(random, bytes)
+		room := mailLINE_MAX - 1 - length
+		for i := 0; len(bytes)-i > room && len(bytes)-i > len(newLine); room = mailLINE_MAX - 1 {
+			i += maxInt(0, minInt(room, len(bytes)-i-1-len(newLine)))
+			bytes[i] = '='
+			i += 1 + copy(bytes[i+1:], newLine)
+		}
+	}
+}
+
+// appendQuotedPrintable appends text encoded as quoted-printable (RFC 2045).
+// Lines starting with "From " are escaped, so mbox needs no escaping.
+func appendQuotedPrintable(dst, text, newLine []byte) []byte {
+	var length int
+	for i, b := range text {
+		if b == '\n' {
+			dst = append(dst, newLine...)
+			length = 0
+			continue
+		}
+		encoded := b == '=' || b < ' ' || b > '~'
+		encoded = encoded || (b == ' ' || b == '\t') && (i+1 == len(text) || text[i+1] == '\n')
+		encoded = encoded || b == 'F' && length == 0 && bytes.HasPrefix(text[i:], []byte("From "))
+		width := 1
+		if encoded {
+			width = 3
+		}
+		if length+width > mailLINE_MAX-1 {
+			dst = append(dst, '=')
+			dst = append(dst, newLine...)
+			length = 0
+			encoded = encoded || b == 'F' && bytes.HasPrefix(text[i:], []byte("From "))
+			if encoded {
+				width = 3
+			}
+		}
+		if encoded {
+			dst = appendHexEscape(dst, b)
+		} else {
+			dst = append(dst, b)
+		}
+		length += width
+	}
+	return dst
+}
+
+// appendBase64 appends data encoded as base64 in lines of 76 characters.
+func appendBase64(dst, data, newLine []byte) []byte {
+	offset := len(dst)
+	dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(data)))...)
+	base64.StdEncoding.Encode(dst[offset:], data)
+	encoded := append([]byte(nil), dst[offset:]...)
+	dst = dst[:offset]
+	for i := 0; i < len(encoded); i += mailLINE_MAX {
+		end := i + mailLINE_MAX
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		dst = append(dst, encoded[i:end]...)
+		dst = append(dst, newLine...)
+	}
+	return dst
+}
+
+func appendHexEscape(dst []byte, b byte) []byte {
+	const digits = "0123456789ABCDEF"
+	return append(dst, '=', digits[b>>4], digits[b&15])
+}
+
+func isASCII(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if str[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/quotedprintable"
+	"net/mail"
+	"testing"
+	"time"
+)
+
+func TestQuotedPrintable(t *testing.T) {
+	text := []byte("From here = there \nx" + string(bytes.Repeat([]byte{'a'}, 100)) + "ä\t\nFrom \n")
+	encoded := appendQuotedPrintable(nil, text, []byte{'\r', '\n'})
+	for _, line := range bytes.Split(encoded, []byte{'\r', '\n'}) {
+		if len(line) > mailLINE_MAX {
+			t.Error("line too long:", string(line))
+		}
+		if bytes.HasPrefix(line, []byte("From ")) {
+			t.Error("From not escaped:", string(line))
+		}
+	}
+	decoded, err := ioutil.ReadAll(quotedprintable.NewReader(bytes.NewReader(encoded)))
+	if err != nil {
+		t.Error(err.Error())
+	} else if string(decoded) != string(bytes.Replace(text, []byte{'\n'}, []byte{'\r', '\n'}, -1)) {
+		t.Error("decoded text differs:", string(decoded))
+	}
+}
+
+func TestMbox(t *testing.T) {
+	format := &tMailFormat{true, time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), 1, 1000, ""}
+	output := generateTest(newRecords(format), 200000, 50000, []byte{'\n'})
+	messages := bytes.Split(output, []byte("\nFrom "))
+	if len(messages) < 2 {
+		t.Fatal("too few messages:", len(messages))
+	}
+	ids := make(map[string]bool)
+	for _, message := range messages[:len(messages)-1] {
+		message = message[bytes.IndexByte(message, '\n')+1:]
+		msg, err := mail.ReadMessage(bytes.NewReader(message))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		id := msg.Header.Get("Message-ID")
+		if ids[id] {
+			t.Error("duplicate Message-ID:", id)
+		}
+		ids[id] = true
+		if _, err = msg.Header.AddressList("To"); err != nil {
+			t.Error(err.Error())
+		}
+	}
+}
+
+func TestMailThreads(t *testing.T) {
+	format := &tMailFormat{true, time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), 1, 1000, ""}
+	for _, sizes := range [][2]int{{2000, 50000}, {3000, 50000}, {5000, 50000}, {200000, 5000}, {200000, 50000}} {
+		output := generateTest(newRecords(format), sizes[0], sizes[1], []byte{'\n'})
+		ids := make(map[string]bool)
+		var replies [][]byte
+		for _, line := range bytes.Split(output, []byte{'\n'}) {
+			if bytes.HasPrefix(line, []byte("Message-ID: ")) {
+				ids[string(line[len("Message-ID: "):])] = true
+			} else if bytes.HasPrefix(line, []byte("In-Reply-To: ")) {
+				replies = append(replies, line[len("In-Reply-To: "):])
+			} else if bytes.HasPrefix(line, []byte("References: ")) {
+				replies = append(replies, bytes.Fields(line[len("References: "):])...)
+			}
+		}
+		for _, id := range replies {
+			if !ids[string(id)] {
+				t.Fatal("message replied to missing:", string(id), sizes)
+			}
+		}
+	}
+}
+
+func TestMailSamples(t *testing.T) {
+	var boundaries []string
+	for seed := int64(1); seed <= 2; seed++ {
+		content := &tContent{seed: seed, randomFill: randomFillZ}
+		content.format = newRecords(&tMailFormat{mbox: true, start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), rate: 1})
+		err := initMail(content, nil)
+		format := content.format.(*tRecords).format.(*tMailFormat)
+		if err != nil || format.lengthAvg <= 1 {
+			t.Error("samples not initialized")
+		}
+		boundaries = append(boundaries, format.boundary)
+	}
+	if boundaries[0] == boundaries[1] {
+		t.Error("seed of output not used")
+	}
+}
@@ -0,0 +1,290 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	markdownDEPTH_DEFAULT = 3
+	markdownDEPTH_MAX     = 6
+)
+
+const (
+	markdownHEADING = iota
+	markdownPARAGRAPH
+	markdownLIST
+	markdownCODE
+	markdownTABLE
+	markdownQUOTE
+)
+
+var markdownElements = []string{"heading", "paragraph", "list", "code", "table", "quote"}
+var markdownMixDefault = []float64{2, 6, 2, 1, 1, 1}
+var codeKeywords = []string{"if", "for", "return", "let", "func", "var", "while"}
+
+// tMarkdownFormat generates Markdown. Records are blocks separated by
+// blank lines.
+type tMarkdownFormat struct {
+	maxDepth int
+	mix      []float64
+}
+
+// tMarkdownState is the level of the current section.
+type tMarkdownState struct {
+	level int
+}
+
+func interpretMarkdown(params *tParameters) (tFormat, error) {
+	var err error
+	format := new(tMarkdownFormat)
+	format.maxDepth, err = interpretDepth(params, markdownDEPTH_DEFAULT, err)
+	if err == nil {
+		if format.maxDepth > markdownDEPTH_MAX {
+			format.maxDepth = markdownDEPTH_MAX
+		}
+		format.mix = markdownMixDefault
+		if params.mix.Available() {
+			format.mix, err = parseWeights(params.mix.Values[0], markdownElements)
+		}
+		if err == nil {
+			return newRecords(format), nil
+		}
+	}
+	return nil, err
+}
+
+// parseWeights parses "name:weight,...". Names not listed have weight 0.
+func parseWeights(str string, names []string) ([]float64, error) {
+	var total float64
+	weights := make([]float64, len(names))
+	for _, pair := range strings.Split(str, ",") {
+		nameWeight := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		index := indexOf(names, nameWeight[0])
+		if index < 0 {
+			return nil, errors.New("unknown element \"" + nameWeight[0] + "\" (expected " + strings.Join(names, ", ") + ")")
+		}
+		weights[index] = 1
+		if len(nameWeight) == 2 {
+			weight, err := strconv.ParseFloat(nameWeight[1], 64)
+			if err != nil || weight < 0 {
+				return nil, errors.New("can't parse weight of \"" + nameWeight[0] + "\"")
+			}
+			weights[index] = weight
+		}
+		total += weights[index]
+	}
+	if total > 0 {
+		return weights, nil
+	}
+	return nil, errors.New("sum of weights is zero")
+}
+
+// chooseWeighted returns a random index of weights.
+func chooseWeighted(weights []float64, generator *tGenerator) int {
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	value := generator.random.Float64() * total
+	for i, weight := range weights {
+		value -= weight
+		if value < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+func indexOf(strs []string, str string) int {
+	for i, strOther := range strs {
+		if strOther == str {
+			return i
+		}
+	}
+	return -1
+}
+
+func (format *tMarkdownFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, '#', ' ')
+	dst = format.appendText(dst, generator, 2+generator.random.Intn(4))
+	dst = append(dst, newLine...)
+	return append(dst, newLine...)
+}
+
+func (format *tMarkdownFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	state, ok := generator.state.(*tMarkdownState)
+	if !ok {
+		state = new(tMarkdownState)
+		state.level = 1
+		generator.state = state
+	}
+	switch chooseWeighted(format.mix, generator) {
+	case markdownHEADING:
+		dst = format.appendHeading(dst, generator, state)
+	case markdownPARAGRAPH:
+		dst = format.appendParagraph(dst, generator, newLine, "")
+	case markdownLIST:
+		dst = format.appendList(dst, generator, newLine)
+	case markdownCODE:
+		dst = format.appendCode(dst, generator, newLine)
+	case markdownTABLE:
+		dst = format.appendTable(dst, generator, newLine)
+	case markdownQUOTE:
+		dst = format.appendParagraph(dst, generator, newLine, "> ")
+	}
+	dst = append(dst, newLine...)
+	return append(dst, newLine...)
+}
+
+func (format *tMarkdownFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// appendHeading appends a heading at most one level below current section.
+func (format *tMarkdownFormat) appendHeading(dst []byte, generator *tGenerator, state *tMarkdownState) []byte {
+	levelMax := state.level + 1
+	if levelMax > format.maxDepth {
+		levelMax = format.maxDepth
+	}
+	state.level = 1 + generator.random.Intn(levelMax)
+	for i := 0; i < state.level; i++ {
+		dst = append(dst, '#')
+	}
+	dst = append(dst, ' ')
+	return format.appendText(dst, generator, 1+generator.random.Intn(6))
+}
+
+// appendParagraph appends lines of text with inline elements. Each line
+// begins with prefix.
+func (format *tMarkdownFormat) appendParagraph(dst []byte, generator *tGenerator, newLine []byte, prefix string) []byte {
+	lines := 1 + generator.random.Intn(5)
+	for i := 0; i < lines; i++ {
+		if i > 0 {
+			dst = append(dst, newLine...)
+		}
+		dst = append(dst, prefix...)
+		dst = format.appendInline(dst, generator, 4+generator.random.Intn(12))
+	}
+	return dst
+}
+
+func (format *tMarkdownFormat) appendList(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	items := 2 + generator.random.Intn(5)
+	numbered := generator.random.Intn(2) == 0
+	for i := 0; i < items; i++ {
+		if i > 0 {
+			dst = append(dst, newLine...)
+		}
+		indent := i > 0 && generator.random.Intn(4) == 0
+		if indent {
+			dst = append(dst, "   "...)
+		}
+		if numbered && !indent {
+			dst = strconv.AppendInt(dst, int64(i+1), 10)
+			dst = append(dst, '.', ' ')
+		} else {
+			dst = append(dst, '-', ' ')
+		}
+		dst = format.appendInline(dst, generator, 1+generator.random.Intn(8))
+	}
+	return dst
+}
+
+func (format *tMarkdownFormat) appendCode(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, "```"...)
+	dst = generator.appendName(dst, 1, 6)
+	lines := 1 + generator.random.Intn(8)
+	for i := 0; i < lines; i++ {
+		dst = append(dst, newLine...)
+		for j := generator.random.Intn(3); j > 0; j-- {
+			dst = append(dst, "    "...)
+		}
+		dst = append(dst, codeKeywords[generator.random.Intn(len(codeKeywords))]...)
+		dst = append(dst, ' ')
+		dst = generator.appendName(dst, 1, 10)
+		dst = append(dst, " = "...)
+		dst = generator.appendName(dst, 1, 10)
+		dst = append(dst, '(')
+		dst = strconv.AppendInt(dst, generator.random.Int63n(1000), 10)
+		dst = append(dst, ");"...)
+	}
+	dst = append(dst, newLine...)
+	return append(dst, "```"...)
+}
+
+func (format *tMarkdownFormat) appendTable(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	columns := 2 + generator.random.Intn(4)
+	rows := 1 + generator.random.Intn(6)
+	for row := -2; row < rows; row++ {
+		if row > -2 {
+			dst = append(dst, newLine...)
+		}
+		dst = append(dst, '|')
+		for column := 0; column < columns; column++ {
+			dst = append(dst, ' ')
+			if row == -1 {
+				dst = append(dst, "---"...)
+			} else {
+				dst = format.appendText(dst, generator, 1+generator.random.Intn(3))
+			}
+			dst = append(dst, ' ', '|')
+		}
+	}
+	return dst
+}
+
+// appendInline appends words with emphasis, strong emphasis, code spans
+// and links.
+func (format *tMarkdownFormat) appendInline(dst []byte, generator *tGenerator, words int) []byte {
+	for i := 0; i < words; i++ {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		switch generator.random.Intn(12) {
+		case 0:
+			dst = append(dst, '*')
+			dst = format.appendText(dst, generator, 1)
+			dst = append(dst, '*')
+		case 1:
+			dst = append(dst, '*', '*')
+			dst = format.appendText(dst, generator, 1)
+			dst = append(dst, '*', '*')
+		case 2:
+			dst = append(dst, '`')
+			dst = generator.appendName(dst, 1, 10)
+			dst = append(dst, '`')
+		case 3:
+			dst = append(dst, '[')
+			dst = format.appendText(dst, generator, 1+generator.random.Intn(3))
+			dst = append(dst, "](https://example.com/"...)
+			dst = generator.appendName(dst, 1, 10)
+			dst = append(dst, ')')
+		default:
+			dst = format.appendText(dst, generator, 1)
+		}
+	}
+	return dst
+}
+
+// appendText appends words with escaped punctuation. Record is stretched
+// by the text.
+func (format *tMarkdownFormat) appendText(dst []byte, generator *tGenerator, words int) []byte {
+	generator.scratch = generator.appendWords(generator.scratch[:0], words)
+	for _, b := range generator.scratch {
+		if b > ' ' && b < '0' || b > '9' && b < 'A' || b > 'Z' && b < 'a' || b > 'z' && b <= '~' {
+			dst = append(dst, '\\')
+		}
+		dst = append(dst, b)
+	}
+	generator.markStretch(len(dst), generator.wordFill())
+	return dst
+}
@@ -0,0 +1,36 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseWeights(t *testing.T) {
+	weights, err := parseWeights("code:2, table", markdownElements)
+	if err != nil || weights[markdownCODE] != 2 || weights[markdownTABLE] != 1 || weights[markdownPARAGRAPH] != 0 {
+		t.Error("wrong weights:", weights, err)
+	}
+	for _, str := range []string{"code:x", "foo:1", "code:0"} {
+		_, err = parseWeights(str, markdownElements)
+		if err == nil {
+			t.Error("invalid weights not recognized:", str)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	format := new(tMarkdownFormat)
+	format.maxDepth = 2
+	format.mix = []float64{0, 0, 0, 0, 1, 0}
+	output := generateTest(newRecords(format), 5000, 2500, []byte{'\n'})
+	if !bytes.HasPrefix(output, []byte("# ")) || !bytes.Contains(output, []byte("\n| --- |")) {
+		t.Error("table missing:", string(output))
+	}
+}
@@ -0,0 +1,270 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	markupDEPTH_DEFAULT  = 4
+	markupFANOUT_DEFAULT = 4
+)
+
+// tMarkupFormat generates XML or HTML. Records are the children of the
+// root element (XML) or of body (HTML). Records are stretched by text or
+// a comment.
+type tMarkupFormat struct {
+	html      bool
+	tags      []string
+	maxDepth  int
+	fanout    int
+	malformed float64
+}
+
+var htmlTags = []string{"div", "section", "article", "aside", "blockquote", "span", "em", "strong", "b", "i", "code", "mark", "small"}
+var htmlVoidTags = []string{"br", "hr", "img", "input"}
+var htmlEntities = []string{"&amp;", "&lt;", "&gt;", "&quot;", "&#169;", "&#x263A;", "&nbsp;", "&copy;", "&eacute;"}
+var xmlEntities = []string{"&amp;", "&lt;", "&gt;", "&quot;", "&apos;", "&#169;", "&#x263A;"}
+
+func interpretMarkup(params *tParameters, html bool) (tFormat, error) {
+	var err error
+	format := new(tMarkupFormat)
+	format.html = html
+	format.maxDepth, err = interpretDepth(params, markupDEPTH_DEFAULT, err)
+	format.fanout, err = interpretFanout(params, markupFANOUT_DEFAULT, err)
+	format.malformed, err = interpretRate(params.malformed, "malformed", err)
+	if err == nil {
+		if params.tags.Available() {
+			for _, tag := range strings.Split(params.tags.Values[0], ",") {
+				tag = strings.TrimSpace(tag)
+				if !isXMLName(tag) {
+					return nil, errors.New("bad tag name \"" + tag + "\"")
+				}
+				format.tags = append(format.tags, tag)
+			}
+		} else if html {
+			format.tags = htmlTags
+		}
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+func (format *tMarkupFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.html {
+		dst = append(dst, "<!DOCTYPE html>"...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "<html>"...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "<head><meta charset=\"utf-8\"><title>"...)
+		dst = generator.appendName(dst, 3, 12)
+		dst = append(dst, "</title></head>"...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "<body>"...)
+	} else {
+		dst = append(dst, xmlDECLARATION...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "<root>"...)
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tMarkupFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = format.appendElement(dst, generator, 1)
+	return append(dst, newLine...)
+}
+
+func (format *tMarkupFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.html {
+		dst = append(dst, "</body>"...)
+		dst = append(dst, newLine...)
+		dst = append(dst, "</html>"...)
+	} else {
+		dst = append(dst, "</root>"...)
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tMarkupFormat) appendElement(dst []byte, generator *tGenerator, depth int) []byte {
+	if format.html && generator.random.Intn(16) == 0 {
+		dst = append(dst, '<')
+		dst = append(dst, htmlVoidTags[generator.random.Intn(len(htmlVoidTags))]...)
+		dst = format.appendAttributes(dst, generator)
+		return append(dst, '>')
+	}
+	offsetName := len(dst) + 1
+	dst = append(dst, '<')
+	if len(format.tags) > 0 {
+		dst = append(dst, format.tags[generator.random.Intn(len(format.tags))]...)
+	} else {
+		dst = appendXMLName(dst, generator, 1, 10)
+	}
+	name := dst[offsetName:len(dst):len(dst)]
+	dst = format.appendAttributes(dst, generator)
+	dst = append(dst, '>')
+	children := 1 + generator.random.Intn(format.fanout)
+	for i := 0; i < children; i++ {
+		dst = format.appendChild(dst, generator, depth)
+	}
+	if format.isMalformed(generator) {
+		return format.appendDefect(dst, generator, name, depth)
+	}
+	dst = append(dst, '<', '/')
+	dst = append(dst, name...)
+	return append(dst, '>')
+}
+
+func (format *tMarkupFormat) appendChild(dst []byte, generator *tGenerator, depth int) []byte {
+	kinds := 5
+	if depth < format.maxDepth {
+		kinds = 8
+	}
+	switch generator.random.Intn(kinds) {
+	case 0, 1:
+		generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(8))
+		dst = appendXMLEscaped(dst, generator.scratch, false)
+		generator.markStretch(len(dst), generator.wordFill())
+		return dst
+	case 2:
+		if format.html {
+			return append(dst, htmlEntities[generator.random.Intn(len(htmlEntities))]...)
+		}
+		return append(dst, xmlEntities[generator.random.Intn(len(xmlEntities))]...)
+	case 3:
+		if format.html {
+			generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(8))
+			dst = appendXMLEscaped(dst, generator.scratch, false)
+			generator.markStretch(len(dst), generator.wordFill())
+			return dst
+		}
+		dst = append(dst, "<![CDATA["...)
+		offset := len(dst)
+		dst = generator.appendWords(dst, 1+generator.random.Intn(8))
+		for i := offset + 2; i < len(dst); i++ {
+			if dst[i] == '>' && dst[i-1] == ']' && dst[i-2] == ']' {
+				dst[i] = ' '
+			}
+		}
+		return append(dst, "]]>"...)
+	case 4:
+		dst = append(dst, "<!-- "...)
+		dst = generator.appendName(dst, 1, 20)
+		generator.markStretch(len(dst), randomFillL)
+		return append(dst, " -->"...)
+	}
+	return format.appendElement(dst, generator, depth+1)
+}
+
+func (format *tMarkupFormat) appendAttributes(dst []byte, generator *tGenerator) []byte {
+	attributes := generator.random.Intn(3)
+	offsets := make([]int, 0, attributes)
+	for i := 0; i < attributes; i++ {
+		dst = append(dst, ' ')
+		offsets = append(offsets, len(dst))
+		dst = appendAttributeName(dst, generator, offsets[:i])
+		dst = append(dst, '=', '"')
+		generator.scratch = generator.appendWords(generator.scratch[:0], 1+generator.random.Intn(3))
+		dst = appendXMLEscaped(dst, generator.scratch, true)
+		dst = append(dst, '"')
+	}
+	return dst
+}
+
+func (format *tMarkupFormat) isMalformed(generator *tGenerator) bool {
+	return format.malformed > 0 && generator.random.Float64() < format.malformed
+}
+
+// appendDefect appends an unclosed tag, misnested tags, a stray closing
+// tag or unescaped text instead of the closing tag.
+func (format *tMarkupFormat) appendDefect(dst []byte, generator *tGenerator, name []byte, depth int) []byte {
+	switch generator.random.Intn(4) {
+	case 0:
+		// unclosed
+		return dst
+	case 1:
+		// misnested
+		dst = append(dst, "<b><i>"...)
+		dst = generator.appendName(dst, 1, 10)
+		dst = append(dst, "</b>"...)
+		dst = append(dst, "</"...)
+		dst = append(dst, name...)
+		return append(dst, "></i>"...)
+	case 2:
+		// stray closing tag
+		dst = append(dst, "</"...)
+		dst = append(dst, name...)
+		dst = append(dst, "></div></"...)
+		dst = append(dst, name...)
+		return append(dst, '>')
+	}
+	// unescaped text
+	dst = append(dst, " a < b && c > d "...)
+	dst = append(dst, "</"...)
+	dst = append(dst, name...)
+	return append(dst, '>')
+}
+
+// appendAttributeName appends a name, that is not at offsets.
+func appendAttributeName(dst []byte, generator *tGenerator, offsets []int) []byte {
+	offset := len(dst)
+	for {
+		dst = appendXMLName(dst, generator, 1, 8)
+		dst = append(dst, '=')
+		if !containsKey(dst, offset, offsets) {
+			return dst[:len(dst)-1]
+		}
+		dst = dst[:offset]
+	}
+}
+
+// appendXMLEscaped appends value with escaped markup characters.
+func appendXMLEscaped(dst, value []byte, attribute bool) []byte {
+	for _, b := range value {
+		switch b {
+		case '&':
+			dst = append(dst, "&amp;"...)
+		case '<':
+			dst = append(dst, "&lt;"...)
+		case '>':
+			dst = append(dst, "&gt;"...)
+		case '"':
+			if attribute {
+				dst = append(dst, "&quot;"...)
+			} else {
+				dst = append(dst, b)
+			}
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
+
+func isXMLName(name string) bool {
+	if len(name) > 0 && isIdentStart(name[0]) && !strings.HasPrefix(strings.ToLower(name), "xml") {
+		for i := 1; i < len(name); i++ {
+			if !isIdentPart(name[i]) && name[i] != '.' {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// appendXMLName appends a random name, that doesn't start with "xml".
+func appendXMLName(dst []byte, generator *tGenerator, lengthMin, lengthMax int) []byte {
+	offset := len(dst)
+	dst = generator.appendName(dst, lengthMin, lengthMax)
+	if hasPrefix(dst[offset:], "xml") {
+		dst[offset] = 'y'
+	}
+	return dst
+}
@@ -0,0 +1,33 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"testing"
+)
+
+func TestMarkupXML(t *testing.T) {
+	format := new(tMarkupFormat)
+	format.maxDepth = 4
+	format.fanout = 4
+	output := generateTest(newRecords(format), 20000, 3000, []byte{'\n'})
+	if err := validateXML(output); err != nil {
+		t.Error("invalid XML:", err.Error())
+	}
+	format.malformed = 1
+	output = generateTest(newRecords(format), 20000, 3000, []byte{'\n'})
+	if err := validateXML(output); err == nil {
+		t.Error("malformed XML is valid")
+	}
+}
+
+func TestXMLName(t *testing.T) {
+	if !isXMLName("item-1") || isXMLName("1item") || isXMLName("xmlns") || isXMLName("a b") {
+		t.Error("wrong validation of XML names")
+	}
+}
@@ -0,0 +1,224 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const numberTYPE_DEFAULT = "int"
+
+var notationsInt = []string{"", "decimal", "hex", "octal", "binary"}
+var notationsFloat = []string{"", "decimal", "scientific", "hex"}
+var distributions = []string{"", "uniform", "normal", "exponential"}
+
+// interpretNumbers returns format of one number per line. The number is
+// described by --number, e.g. "float:min=0:max=1:precision=4".
+func interpretNumbers(params *tParameters) (tFormat, error) {
+	spec := numberTYPE_DEFAULT
+	if params.number.Available() {
+		spec = params.number.Values[0]
+	}
+	column, err := parseColumnSpec(spec)
+	if err == nil {
+		format := new(tCSVFormat)
+		format.schema = new(tSchema)
+		format.schema.Columns = []*tColumn{column}
+		format.delimiter = []byte{','}
+		format.quotes = []int{quoteNONE}
+		return newRecords(format), nil
+	}
+	return nil, err
+}
+
+// parseColumnSpec returns column of type with properties separated by
+// colon, e.g. "int:min=1:max=6".
+func parseColumnSpec(spec string) (*tColumn, error) {
+	var err error
+	properties := strings.Split(spec, ":")
+	column := newColumn("", strings.TrimSpace(properties[0]))
+	for _, property := range properties[1:] {
+		keyValue := strings.SplitN(property, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, errors.New("number, expected key=value, got \"" + property + "\"")
+		}
+		switch keyValue[0] {
+		case "min":
+			column.Min, err = parseFloatPtr(keyValue[1])
+		case "max":
+			column.Max, err = parseFloatPtr(keyValue[1])
+		case "precision":
+			var precision int
+			precision, err = strconv.Atoi(keyValue[1])
+			column.Precision = &precision
+		case "notation":
+			column.Notation = keyValue[1]
+		case "distribution":
+			column.Distribution = keyValue[1]
+		case "decimal":
+			column.Decimal = keyValue[1]
+		default:
+			return nil, errors.New("number, unknown property \"" + keyValue[0] + "\"")
+		}
+		if err != nil {
+			return nil, errors.New("number, can't parse " + keyValue[0])
+		}
+	}
+	err = column.init()
+	if err == nil {
+		return column, nil
+	}
+	return nil, errors.New("number, " + err.Error())
+}
+
+func parseFloatPtr(str string) (*float64, error) {
+	value, err := strconv.ParseFloat(str, 64)
+	return &value, err
+}
+
+// initNumber checks notation, distribution and range of integers. Max
+// 2^63 is the maximum of int64, i.e. it isn't representable as float64.
+func (column *tColumn) initNumber() error {
+	notations := notationsInt
+	if column.Type == "float" {
+		notations = notationsFloat
+	} else if column.min < math.MinInt64 || column.max > -math.MinInt64 {
+		return errors.New("min or max is out of range of 64-bit integers")
+	} else {
+		column.minInt, column.maxInt = int64(column.min), math.MaxInt64
+		if column.max < -math.MinInt64 {
+			column.maxInt = int64(column.max)
+		}
+	}
+	if indexOf(notations, column.Notation) < 0 {
+		return errors.New("unknown notation \"" + column.Notation + "\" (expected " + strings.Join(notations[1:], ", ") + ")")
+	}
+	if indexOf(distributions, column.Distribution) < 0 {
+		return errors.New("unknown distribution \"" + column.Distribution + "\" (expected " + strings.Join(distributions[1:], ", ") + ")")
+	}
+	return nil
+}
+
+// randFloat returns a random number in [min, max]. Normal distribution has
+// mean in the middle of range, exponential distribution has mean at 1/4 of
+// range.
+func (column *tColumn) randFloat(generator *tGenerator) float64 {
+	switch column.Distribution {
+	case "normal":
+		for {
+			value := (column.min+column.max)/2 + generator.random.NormFloat64()*(column.max-column.min)/6
+			if value >= column.min && value <= column.max {
+				return value
+			}
+		}
+	case "exponential":
+		for {
+			value := column.min + generator.random.ExpFloat64()*(column.max-column.min)/4
+			if value <= column.max {
+				return value
+			}
+		}
+	}
+	return column.min + generator.random.Float64()*(column.max-column.min)
+}
+
+func (column *tColumn) randInt(generator *tGenerator) int64 {
+	if len(column.Distribution) == 0 || column.Distribution == "uniform" {
+		return column.minInt + int64(randUint64(generator.random, uint64(column.maxInt)-uint64(column.minInt)))
+	}
+	if value := math.Round(column.randFloat(generator)); value < -math.MinInt64 {
+		return int64(value)
+	}
+	return column.maxInt
+}
+
+// randUint64 returns a random number in [0, max]. Unlike Int63n, max may
+// be the range of any two int64.
+func randUint64(random *rand.Rand, max uint64) uint64 {
+	if max < math.MaxInt64 {
+		return uint64(random.Int63n(int64(max + 1)))
+	}
+	for {
+		if value := random.Uint64(); value <= max {
+			return value
+		}
+	}
+}
+
+// appendInteger appends value in notation of column. Hexadecimal, octal
+// and binary numbers have prefix 0x, 0o and 0b. Negation is unsigned, so
+// the minimum of int64 is appended, too.
+func (column *tColumn) appendInteger(dst []byte, value int64) []byte {
+	base := 10
+	magnitude := uint64(value)
+	if value < 0 {
+		dst = append(dst, '-')
+		magnitude = -magnitude
+	}
+	switch column.Notation {
+	case "hex":
+		dst, base = append(dst, '0', 'x'), 16
+	case "octal":
+		dst, base = append(dst, '0', 'o'), 8
+	case "binary":
+		dst, base = append(dst, '0', 'b'), 2
+	}
+	return strconv.AppendUint(dst, magnitude, base)
+}
+
+// appendFloat appends value in notation of column with decimal separator
+// of column.
+func (column *tColumn) appendFloat(dst []byte, value float64) []byte {
+	offset := len(dst)
+	precision := 2
+	if column.Precision != nil {
+		precision = *column.Precision
+	}
+	switch column.Notation {
+	case "scientific":
+		dst = strconv.AppendFloat(dst, value, 'e', precision, 64)
+	case "hex":
+		dst = strconv.AppendFloat(dst, value, 'x', precision, 64)
+	default:
+		dst = strconv.AppendFloat(dst, value, 'f', precision, 64)
+	}
+	if len(column.Decimal) > 0 && column.Decimal != "." {
+		if point := bytes.IndexByte(dst[offset:], '.'); point >= 0 {
+			dst = append(dst[:offset+point], append([]byte(column.Decimal), dst[offset+point+1:]...)...)
+		}
+	}
+	return dst
+}
+
+// maxNumberLength returns the maximum length of numbers of column.
+func (column *tColumn) maxNumberLength() int {
+	var minimum, maximum []byte
+	if column.Type == "float" {
+		// rounding may add a digit
+		minimum = column.appendFloat(nil, column.min*1.1)
+		maximum = column.appendFloat(nil, column.max*1.1)
+	} else {
+		minimum = column.appendInteger(nil, column.minInt)
+		maximum = column.appendInteger(nil, column.maxInt)
+	}
+	if len(minimum) > len(maximum) {
+		return len(minimum)
+	}
+	return len(maximum)
+}
+
+// appendFieldSeq appends min plus number of record in output, i.e. values
+// are consecutive.
+func appendFieldSeq(dst []byte, column *tColumn, generator *tGenerator) []byte {
+	return column.appendInteger(dst, column.minInt+generator.records)
+}
@@ -0,0 +1,137 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestColumnSpec(t *testing.T) {
+	column, err := parseColumnSpec("float:min=-1:max=1:precision=3:notation=scientific:decimal=,")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if column.min != -1 || column.max != 1 || *column.Precision != 3 || column.isNumeric() {
+		t.Error("wrong column:", column)
+	}
+	if string(column.appendFloat(nil, 0.5)) != "5,000e-01" {
+		t.Error("wrong float:", string(column.appendFloat(nil, 0.5)))
+	}
+	for _, spec := range []string{"int:notation=scientific", "int:min", "int:foo=1", "int:distribution=zipf", "int:min=3:max=2", "int:max=1e19", "seq:min=-1e19", "int:min=NaN"} {
+		_, err = parseColumnSpec(spec)
+		if err == nil {
+			t.Error("invalid spec not recognized:", spec)
+		}
+	}
+}
+
+func TestNotations(t *testing.T) {
+	column := newColumn("", "int")
+	notations := []string{"", "hex", "octal", "binary"}
+	values := []string{"-10", "-0xa", "-0o12", "-0b1010"}
+	for i, notation := range notations {
+		column.Notation = notation
+		if string(column.appendInteger(nil, -10)) != values[i] {
+			t.Error("wrong integer:", string(column.appendInteger(nil, -10)))
+		}
+	}
+	column.Notation = ""
+	if string(column.appendInteger(nil, math.MinInt64)) != "-9223372036854775808" {
+		t.Error("wrong integer:", string(column.appendInteger(nil, math.MinInt64)))
+	}
+}
+
+func TestIntRange(t *testing.T) {
+	generator := newGenerator(0, new(tContent))
+	column, err := parseColumnSpec("int:min=-9223372036854775808:max=9223372036854775808")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var negative, positive int
+	for i := 0; i < 1000; i++ {
+		if column.randInt(generator) < 0 {
+			negative++
+		} else {
+			positive++
+		}
+	}
+	if negative < 400 || positive < 400 {
+		t.Error("not uniform:", negative, positive)
+	}
+}
+
+func TestDistributions(t *testing.T) {
+	generator := newGenerator(0, new(tContent))
+	for _, distribution := range distributions {
+		column, err := parseColumnSpec("int:min=10:max=20:distribution=" + distribution)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for i := 0; i < 1000; i++ {
+			value := column.randInt(generator)
+			if value < 10 || value > 20 {
+				t.Error("value out of range:", value, distribution)
+			}
+		}
+	}
+}
+
+func TestSeq(t *testing.T) {
+	column, err := parseColumnSpec("seq:min=1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	format := new(tCSVFormat)
+	format.schema = &tSchema{Columns: []*tColumn{column}}
+	format.delimiter = []byte{','}
+	format.quotes = []int{quoteNONE}
+	output := generateTest(newRecords(format), 10000, 333, []byte{'\n'})
+	records := int64(0)
+	for _, line := range bytes.Split(output, []byte{'\n'}) {
+		if len(line) > 0 {
+			records++
+			if string(line) != strconv.FormatInt(records, 10) {
+				t.Fatal("not consecutive:", string(line), records)
+			}
+		}
+	}
+	if records < 1000 || !bytes.HasSuffix(output, []byte{'\n'}) {
+		t.Error("last line not terminated")
+	}
+	content := &tContent{format: newRecords(format)}
+	if checkSequential(content, 1, nil) != nil || checkSequential(content, 2, nil) == nil {
+		t.Error("threads not checked")
+	}
+}
+
+func TestNumbersNotStretched(t *testing.T) {
+	for _, spec := range []string{"int", "float:precision=2", "int:notation=hex"} {
+		column, err := parseColumnSpec(spec)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		format := new(tCSVFormat)
+		format.schema = &tSchema{Columns: []*tColumn{column}}
+		format.delimiter = []byte{','}
+		format.quotes = []int{quoteNONE}
+		output := generateTest(newRecords(format), 10000, 333, []byte{'\n'})
+		if len(output) != 10000 || bytes.IndexByte(output, 0) >= 0 {
+			t.Error("buffers not filled:", spec)
+		}
+		for _, line := range bytes.Split(output, []byte{'\n'}) {
+			digits := bytes.TrimPrefix(bytes.TrimPrefix(line, []byte{'-'}), []byte("0x"))
+			point := bytes.IndexByte(line, '.')
+			if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' || point >= 0 && len(line)-point-1 != 2 {
+				t.Fatal("number stretched:", string(line))
+			}
+		}
+	}
+}
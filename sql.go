@@ -0,0 +1,296 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	sqlBATCH_DEFAULT = 100
+	sqlTABLE_DEFAULT = "data"
+)
+
+const (
+	dialectPOSTGRES = iota
+	dialectMYSQL
+	dialectSQLITE
+)
+
+// tSQLFormat generates a dump with CREATE TABLE and INSERT statements.
+// Records are rows. An INSERT statement is closed after batch rows or at
+// the end of buffer. Rows are stretched by a number or a string of type
+// TEXT.
+type tSQLFormat struct {
+	schema  *tSchema
+	dialect int
+	batch   int
+	insert  []byte
+}
+
+// tSQLState is the number of rows in the open INSERT statement.
+type tSQLState struct {
+	rows int
+}
+
+func interpretSQL(params *tParameters) (tFormat, error) {
+	var path string
+	if params.schema.Available() {
+		path = params.schema.Values[0]
+	}
+	schema, err := readSchema(path, columnsCSVDefault())
+	if err == nil {
+		format := new(tSQLFormat)
+		format.schema = schema
+		format.dialect, err = interpretDialect(params, err)
+		format.batch, err = interpretBatch(params, err)
+		if err == nil {
+			if len(schema.Table) == 0 {
+				schema.Table = sqlTABLE_DEFAULT
+			}
+			for _, column := range schema.Columns {
+				if len(column.SQL) == 0 {
+					column.SQL = format.columnType(column)
+				}
+			}
+			format.insert = append(format.insert, "INSERT INTO "...)
+			format.insert = format.appendIdentifier(format.insert, schema.Table)
+			format.insert = append(format.insert, " ("...)
+			for i, column := range schema.Columns {
+				if i > 0 {
+					format.insert = append(format.insert, ", "...)
+				}
+				format.insert = format.appendIdentifier(format.insert, column.Name)
+			}
+			format.insert = append(format.insert, ") VALUES"...)
+			return newRecords(format), nil
+		}
+	}
+	return nil, err
+}
+
+func interpretDialect(params *tParameters, err error) (int, error) {
+	if err == nil && params.dialect.Available() {
+		switch strings.ToLower(params.dialect.Values[0]) {
+		case "postgres", "postgresql":
+			return dialectPOSTGRES, nil
+		case "mysql", "mariadb":
+			return dialectMYSQL, nil
+		case "sqlite":
+			return dialectSQLITE, nil
+		}
+		return 0, errors.New("unknown SQL dialect \"" + params.dialect.Values[0] + "\"")
+	}
+	return dialectPOSTGRES, err
+}
+
+func interpretBatch(params *tParameters, err error) (int, error) {
+	if err == nil {
+		if params.batch.Available() {
+			batch, err := strconv.Atoi(params.batch.Values[0])
+			if err == nil && batch > 0 {
+				return batch, nil
+			}
+			return 0, errors.New("can't parse batch")
+		}
+		return sqlBATCH_DEFAULT, nil
+	}
+	return 0, err
+}
+
+// columnType returns the SQL type of values generated for column.
+func (format *tSQLFormat) columnType(column *tColumn) string {
+	if column.fieldType.kind == fieldNUMBER && !column.isNumeric() {
+		if format.dialect == dialectSQLITE {
+			return "TEXT"
+		}
+		return "VARCHAR(" + strconv.Itoa(column.maxLength()) + ")"
+	}
+	switch column.Type {
+	case "int", "seq":
+		if column.min < math.MinInt32 || column.max > math.MaxInt32 {
+			return "BIGINT"
+		}
+		return "INTEGER"
+	case "float":
+		switch format.dialect {
+		case dialectPOSTGRES:
+			return "DOUBLE PRECISION"
+		case dialectMYSQL:
+			return "DOUBLE"
+		}
+		return "REAL"
+	case "bool":
+		return "BOOLEAN"
+	case "uuid":
+		switch format.dialect {
+		case dialectPOSTGRES:
+			return "UUID"
+		case dialectMYSQL:
+			return "CHAR(36)"
+		}
+		return "TEXT"
+	case "date":
+		if len(column.Layout) == 0 || column.Layout == dateLAYOUT {
+			return "DATE"
+		}
+		return "TEXT"
+	}
+	if format.dialect == dialectSQLITE {
+		return "TEXT"
+	}
+	return "VARCHAR(" + strconv.Itoa(column.maxLength()) + ")"
+}
+
+// maxLength returns the maximum length of strings generated for column.
+func (column *tColumn) maxLength() int {
+	var length int
+	switch column.Type {
+	case "word":
+		length = wordLEN_MAX
+	case "words":
+		length = int(column.max) * (wordLEN_MAX + 1)
+	case "pick":
+		for _, value := range column.Values {
+			if len(value) > length {
+				length = len(value)
+			}
+		}
+	default:
+		if column.fieldType.kind == fieldNUMBER {
+			length = column.maxNumberLength()
+		} else {
+			length = int(column.max)
+		}
+	}
+	if column.Quotes > 0 {
+		length++
+	}
+	if column.NewLines > 0 {
+		length += 2
+	}
+	if length > 0 {
+		return length
+	}
+	return 1
+}
+
+// sequential returns true, if records are numbered by a column of type
+// seq.
+func (format *tSQLFormat) sequential() bool {
+	return format.schema.sequential()
+}
+
+func (format *tSQLFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, "BEGIN;"...)
+	dst = append(dst, newLine...)
+	dst = append(dst, "CREATE TABLE "...)
+	dst = format.appendIdentifier(dst, format.schema.Table)
+	dst = append(dst, " ("...)
+	for i, column := range format.schema.Columns {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, newLine...)
+		dst = append(dst, "  "...)
+		dst = format.appendIdentifier(dst, column.Name)
+		dst = append(dst, ' ')
+		dst = append(dst, column.SQL...)
+		if column.Null <= 0 {
+			dst = append(dst, " NOT NULL"...)
+		}
+	}
+	dst = append(dst, newLine...)
+	dst = append(dst, ");"...)
+	return append(dst, newLine...)
+}
+
+func (format *tSQLFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	state, ok := generator.state.(*tSQLState)
+	if !ok {
+		state = new(tSQLState)
+		generator.state = state
+	}
+	if state.rows == 0 {
+		dst = append(dst, format.insert...)
+		dst = append(dst, newLine...)
+	}
+	dst = append(dst, '(')
+	for i, column := range format.schema.Columns {
+		if i > 0 {
+			dst = append(dst, ", "...)
+		}
+		if column.isNull(generator) {
+			dst = append(dst, "NULL"...)
+		} else if column.isNumeric() {
+			dst = column.appendValue(dst, generator, newLine)
+		} else {
+			generator.scratch = column.appendValue(generator.scratch[:0], generator, newLine)
+			dst = format.appendString(dst, generator.scratch)
+			// length of VARCHAR is limited
+			if stretch, fill := column.stretchOffset(generator.scratch, generator); stretch == len(generator.scratch) && strings.EqualFold(column.SQL, "TEXT") {
+				generator.markStretch(len(dst)-1, fill)
+			}
+		}
+	}
+	dst = append(dst, ')')
+	state.rows++
+	if state.rows < format.batch {
+		dst = append(dst, ',')
+	} else {
+		dst = append(dst, ';')
+		state.rows = 0
+	}
+	return append(dst, newLine...)
+}
+
+func (format *tSQLFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	dst = append(dst, "COMMIT;"...)
+	return append(dst, newLine...)
+}
+
+// close closes the open INSERT statement, i.e. replaces the comma after
+// the last row of buffer with a semicolon.
+func (format *tSQLFormat) close(generator *tGenerator, newLine []byte) {
+	comma := generator.written - len(newLine) - 1
+	if comma >= 0 && generator.bytes[comma] == ',' {
+		generator.bytes[comma] = ';'
+	}
+}
+
+// appendIdentifier appends name as quoted identifier.
+func (format *tSQLFormat) appendIdentifier(dst []byte, name string) []byte {
+	quote := byte('"')
+	if format.dialect == dialectMYSQL {
+		quote = '`'
+	}
+	dst = append(dst, quote)
+	for i := 0; i < len(name); i++ {
+		if name[i] == quote {
+			dst = append(dst, quote)
+		}
+		dst = append(dst, name[i])
+	}
+	return append(dst, quote)
+}
+
+// appendString appends value as string literal. Quotes are doubled,
+// MySQL also needs backslashes doubled.
+func (format *tSQLFormat) appendString(dst, value []byte) []byte {
+	dst = append(dst, '\'')
+	for _, b := range value {
+		if b == '\'' || b == '\\' && format.dialect == dialectMYSQL {
+			dst = append(dst, b)
+		}
+		dst = append(dst, b)
+	}
+	return append(dst, '\'')
+}
@@ -0,0 +1,56 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSQLString(t *testing.T) {
+	format := new(tSQLFormat)
+	value := []byte("a'b\\c")
+	if string(format.appendString(nil, value)) != "'a''b\\c'" {
+		t.Error("wrong escaping:", string(format.appendString(nil, value)))
+	}
+	format.dialect = dialectMYSQL
+	if string(format.appendString(nil, value)) != "'a''b\\\\c'" {
+		t.Error("wrong escaping:", string(format.appendString(nil, value)))
+	}
+	if string(format.appendIdentifier(nil, "a`b")) != "`a``b`" {
+		t.Error("wrong identifier:", string(format.appendIdentifier(nil, "a`b")))
+	}
+}
+
+func TestSQLStatements(t *testing.T) {
+	schema, err := readSchema("", columnsCSVDefault())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	schema.Table = "t"
+	format := new(tSQLFormat)
+	format.schema = schema
+	format.batch = 7
+	format.insert = []byte("INSERT")
+	output := generateTest(newRecords(format), 20000, 1500, []byte{'\n'})
+	if !bytes.HasPrefix(output, []byte("BEGIN;\nCREATE TABLE \"t\"")) || !bytes.HasSuffix(output, []byte("\nCOMMIT;\n")) {
+		t.Error("head or tail missing")
+	}
+	// last row before INSERT or COMMIT closes the statement
+	var last []byte
+	for _, line := range bytes.Split(output, []byte{'\n'}) {
+		if bytes.HasPrefix(line, []byte("INSERT")) || bytes.HasPrefix(line, []byte("COMMIT")) {
+			if len(last) > 0 && !bytes.HasSuffix(last, []byte(");")) {
+				t.Error("statement not closed:", string(last))
+			}
+		}
+		if len(line) > 0 {
+			last = line
+		}
+	}
+}
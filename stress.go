@@ -0,0 +1,211 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	stressSTRING = iota
+	stressARRAY
+	stressESCAPE
+	stressNUMBER
+)
+
+const xmlDECLARATION = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"
+
+// tNestingFormat generates one value nested as deep as output size allows.
+// Every byte depends on its position only.
+type tNestingFormat struct {
+	xml bool
+}
+
+// tStressFormat generates one document with a huge string, a long array,
+// keys with every escape sequence or numbers at limits of float64. Records
+// are stretched by letters or by whitespace before elements.
+type tStressFormat struct {
+	xml  bool
+	kind int
+}
+
+var stressEscapesJSON = []string{"\\\"", "\\\\", "\\/", "\\b", "\\f", "\\n", "\\r", "\\t", "\\u0000", "\\u001f", "\\u00e9", "\\u20ac", "\\uffff", "\\ud83d\\ude00", "é", "€", "\U0001f600"}
+var stressEscapesXML = []string{"&amp;", "&lt;", "&gt;", "&quot;", "&apos;", "&#9;", "&#10;", "&#xD;", "&#xe9;", "&#8364;", "&#x1F600;", "é", "€", "\U0001f600"}
+var stressNumbers = []string{"0", "-0", "0.0", "-0.0", "1e308", "1.7976931348623157e308", "-1.7976931348623157E+308", "5e-324", "4.9406564584124654e-324", "2.2250738585072014e-308", "2.2250738585072011e-308", "9007199254740992", "9007199254740993", "-9007199254740993", "9223372036854775807", "9223372036854775808", "-9223372036854775809", "18446744073709551616", "0.1", "0.30000000000000004", "1.00000000000000011102230246251565404236316680908203125", "123456789012345678901234567890", "1E400", "1e-400", "0.000000000000000000000000000000000000000000001"}
+
+func interpretStress(params *tParameters, xml bool) (tFormat, error) {
+	kind := strings.ToLower(params.stress.Values[0])
+	if kind == "depth" {
+		format := new(tNestingFormat)
+		format.xml = xml
+		return format, nil
+	}
+	format := new(tStressFormat)
+	format.xml = xml
+	switch kind {
+	case "string":
+		format.kind = stressSTRING
+	case "array":
+		format.kind = stressARRAY
+	case "escape":
+		format.kind = stressESCAPE
+	case "number":
+		format.kind = stressNUMBER
+	default:
+		return nil, errors.New("unknown stress test \"" + params.stress.Values[0] + "\"")
+	}
+	return newRecords(format), nil
+}
+
+func (format *tNestingFormat) fill(generator *tGenerator, newLine []byte) {
+	opening, closing := "[", "]"
+	if format.xml {
+		opening, closing = "<a>", "</a>"
+	}
+	lengthBody := generator.total - len(newLine)
+	depth := lengthBody / (len(opening) + len(closing))
+	textStart := depth * len(opening)
+	closingStart := lengthBody - depth*len(closing)
+	for i := range generator.bytes {
+		position := generator.offset + i
+		if position < textStart {
+			generator.bytes[i] = opening[position%len(opening)]
+		} else if position < closingStart {
+			generator.bytes[i] = ' '
+		} else if position < lengthBody {
+			generator.bytes[i] = closing[(position-closingStart)%len(closing)]
+		} else {
+			generator.bytes[i] = newLine[position-lengthBody]
+		}
+	}
+}
+
+func (format *tStressFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.xml {
+		dst = append(dst, xmlDECLARATION...)
+		dst = append(dst, newLine...)
+		switch format.kind {
+		case stressSTRING:
+			return append(dst, "<s>"...)
+		case stressESCAPE:
+			dst = append(dst, "<doc>"...)
+			return append(dst, newLine...)
+		}
+		return append(dst, "<a>"...)
+	}
+	switch format.kind {
+	case stressSTRING:
+		return append(dst, "[\""...)
+	case stressESCAPE:
+		dst = append(dst, '{')
+		return append(dst, newLine...)
+	}
+	return append(dst, '[')
+}
+
+func (format *tStressFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	switch format.kind {
+	case stressSTRING:
+		escapes := stressEscapesJSON
+		if format.xml {
+			escapes = stressEscapesXML
+		}
+		generator.markStretch(len(dst), randomFillA)
+		dst = generator.appendRandom(dst, generator.random.Intn(64), randomFillA)
+		return append(dst, escapes[generator.random.Intn(len(escapes))]...)
+	case stressARRAY:
+		generator.markStretch(len(dst), randomFillSpaces)
+		if format.xml {
+			dst = append(dst, "<i>"...)
+			dst = strconv.AppendInt(dst, generator.random.Int63n(1000), 10)
+			return append(dst, "</i>"...)
+		}
+		dst = strconv.AppendInt(dst, generator.random.Int63n(1000), 10)
+		return append(dst, ',')
+	case stressESCAPE:
+		if format.xml {
+			dst = append(dst, "<e a=\""...)
+			dst = format.appendEscapes(dst, generator, stressEscapesXML)
+			dst = append(dst, "\">"...)
+			dst = format.appendEscapes(dst, generator, stressEscapesXML)
+			dst = append(dst, "</e>"...)
+		} else {
+			dst = append(dst, '"')
+			dst = format.appendEscapes(dst, generator, stressEscapesJSON)
+			dst = append(dst, "\":\""...)
+			dst = format.appendEscapes(dst, generator, stressEscapesJSON)
+			dst = append(dst, "\","...)
+		}
+		return append(dst, newLine...)
+	}
+	generator.markStretch(len(dst), randomFillSpaces)
+	if format.xml {
+		dst = append(dst, "<n>"...)
+		dst = appendStressNumber(dst, generator)
+		return append(dst, "</n>"...)
+	}
+	dst = appendStressNumber(dst, generator)
+	return append(dst, ',')
+}
+
+func (format *tStressFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	if format.xml {
+		switch format.kind {
+		case stressSTRING:
+			dst = append(dst, "</s>"...)
+		case stressESCAPE:
+			dst = append(dst, "</doc>"...)
+		default:
+			dst = append(dst, "</a>"...)
+		}
+	} else {
+		switch format.kind {
+		case stressSTRING:
+			dst = append(dst, "\"]"...)
+		case stressESCAPE:
+			dst = append(dst, "\"\":0}"...)
+		default:
+			dst = append(dst, "0]"...)
+		}
+	}
+	return append(dst, newLine...)
+}
+
+// appendEscapes appends every escape sequence in random order. Position
+// in output makes the result unique.
+func (format *tStressFormat) appendEscapes(dst []byte, generator *tGenerator, escapes []string) []byte {
+	dst = strconv.AppendInt(dst, int64(generator.position()), 10)
+	generator.markStretch(len(dst), randomFillA)
+	for _, i := range generator.random.Perm(len(escapes)) {
+		dst = append(dst, escapes[i]...)
+	}
+	return dst
+}
+
+func appendStressNumber(dst []byte, generator *tGenerator) []byte {
+	switch generator.random.Intn(4) {
+	case 0:
+		return append(dst, stressNumbers[generator.random.Intn(len(stressNumbers))]...)
+	case 1:
+		// random bits, i.e. any magnitude, shortest representation
+		value := math.Float64frombits(generator.random.Uint64())
+		for math.IsNaN(value) || math.IsInf(value, 0) {
+			value = math.Float64frombits(generator.random.Uint64())
+		}
+		return strconv.AppendFloat(dst, value, 'g', -1, 64)
+	case 2:
+		// 17 significant digits
+		value := generator.random.Float64() * math.Pow(10, float64(generator.random.Intn(40)-20))
+		return strconv.AppendFloat(dst, value, 'e', 16, 64)
+	}
+	// integers near 2^53
+	return strconv.AppendInt(dst, 1<<53-1024+generator.random.Int63n(2048), 10)
+}
@@ -0,0 +1,55 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"testing"
+)
+
+func TestStressJSON(t *testing.T) {
+	output := generateTest(new(tNestingFormat), 2001, 100, []byte{'\n'})
+	if !json.Valid(output) || output[999] != '[' || output[1001] != ']' {
+		t.Error("invalid nesting")
+	}
+	for kind := stressSTRING; kind <= stressNUMBER; kind++ {
+		format := new(tStressFormat)
+		format.kind = kind
+		output = generateTest(newRecords(format), 5000, 1000, []byte{'\n'})
+		if len(output) != 5000 || !json.Valid(output) {
+			t.Error("invalid JSON:", string(output))
+		}
+	}
+}
+
+func TestStressXML(t *testing.T) {
+	for kind := stressSTRING; kind <= stressNUMBER; kind++ {
+		format := new(tStressFormat)
+		format.xml = true
+		format.kind = kind
+		output := generateTest(newRecords(format), 5000, 1000, []byte{'\n'})
+		if err := validateXML(output); err != nil {
+			t.Error("invalid XML:", err.Error())
+		}
+	}
+}
+
+func validateXML(output []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(output))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,303 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	dateLAYOUT = "2006-01-02"
+	dateMIN    = 0          // 1970-01-01
+	dateMAX    = 2145916800 // 2038-01-01
+)
+
+// tTemplateFormat generates records by executing a template. Numbered is
+// true, if the template calls seq.
+type tTemplateFormat struct {
+	template *template.Template
+	numbered bool
+}
+
+// tTemplateState is the template of a generator bound to its functions.
+// Word is the last word of a function and offset its offset in output, i.e.
+// the point, where record is stretched, if the word is written there.
+type tTemplateState struct {
+	template *template.Template
+	writer   *tAppender
+	word     string
+	offset   int
+	fill     func(*rand.Rand, []byte)
+}
+
+// tAppender is a writer, that appends bytes to a slice.
+type tAppender struct {
+	bytes []byte
+}
+
+func interpretTemplate(params *tParameters) (tFormat, error) {
+	text, err := ioutil.ReadFile(params.template.Values[0])
+	if err == nil {
+		format := new(tTemplateFormat)
+		format.template, err = template.New("record").Funcs(templateFuncs(nil, nil)).Parse(string(text))
+		if err == nil {
+			format.numbered = callsFunc(format.template, "seq")
+			err = format.validate()
+			if err == nil {
+				return newRecords(format), nil
+			}
+		}
+		return nil, err
+	}
+	return nil, errors.New("can't read template file")
+}
+
+// validate executes template once to report errors before output is created.
+func (format *tTemplateFormat) validate() error {
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	generator := newGenerator(0, content)
+	state, err := format.newState(generator)
+	if err == nil {
+		err = state.template.Execute(state.writer, nil)
+	}
+	return err
+}
+
+// callsFunc returns true, if a template of tmpl calls function name.
+func callsFunc(tmpl *template.Template, name string) bool {
+	for _, tmpl := range tmpl.Templates() {
+		if tmpl.Tree != nil && nodeCallsFunc(tmpl.Tree.Root, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeCallsFunc(node parse.Node, name string) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for i := 0; node != nil && i < len(node.Nodes); i++ {
+			if nodeCallsFunc(node.Nodes[i], name) {
+				return true
+			}
+		}
+	case *parse.PipeNode:
+		for i := 0; node != nil && i < len(node.Cmds); i++ {
+			if nodeCallsFunc(node.Cmds[i], name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if nodeCallsFunc(arg, name) {
+				return true
+			}
+		}
+	case *parse.IdentifierNode:
+		return node.Ident == name
+	case *parse.ActionNode:
+		return nodeCallsFunc(node.Pipe, name)
+	case *parse.TemplateNode:
+		return nodeCallsFunc(node.Pipe, name)
+	case *parse.IfNode:
+		return branchCallsFunc(&node.BranchNode, name)
+	case *parse.RangeNode:
+		return branchCallsFunc(&node.BranchNode, name)
+	case *parse.WithNode:
+		return branchCallsFunc(&node.BranchNode, name)
+	}
+	return false
+}
+
+func branchCallsFunc(node *parse.BranchNode, name string) bool {
+	return nodeCallsFunc(node.Pipe, name) || nodeCallsFunc(node.List, name) || nodeCallsFunc(node.ElseList, name)
+}
+
+// sequential returns true, if records are numbered by seq.
+func (format *tTemplateFormat) sequential() bool {
+	return format.numbered
+}
+
+func (format *tTemplateFormat) newState(generator *tGenerator) (*tTemplateState, error) {
+	var err error
+	state := new(tTemplateState)
+	state.writer = new(tAppender)
+	state.template, err = format.template.Clone()
+	if err == nil {
+		state.template.Funcs(templateFuncs(generator, state))
+	}
+	return state, err
+}
+
+func (format *tTemplateFormat) head(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+func (format *tTemplateFormat) record(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	state, ok := generator.state.(*tTemplateState)
+	if !ok {
+		state, generator.err = format.newState(generator)
+		if generator.err != nil {
+			return dst
+		}
+		generator.state = state
+	}
+	offset := len(dst)
+	state.writer.bytes = dst
+	state.word = ""
+	generator.err = state.template.Execute(state.writer, nil)
+	if generator.err == nil {
+		dst = state.writer.bytes
+		stretch := state.offset + len(state.word)
+		if stretch > len(dst) {
+			stretch = offset
+		}
+		if len(newLine) != 1 || newLine[0] != '\n' {
+			stretch += bytes.Count(dst[offset:stretch], []byte{'\n'}) * (len(newLine) - 1)
+			dst = append(dst[:offset], bytes.Replace(dst[offset:], []byte{'\n'}, newLine, -1)...)
+		}
+		if len(state.word) > 0 && bytes.HasSuffix(dst[:stretch], []byte(state.word)) {
+			generator.markStretch(stretch-offset, state.fill)
+		}
+		return dst
+	}
+	return dst[:offset]
+}
+
+func (format *tTemplateFormat) tail(dst []byte, generator *tGenerator, newLine []byte) []byte {
+	return dst
+}
+
+// remember remembers word of a function, which is written at the end of
+// output, if the function is called in an action without pipeline.
+func (state *tTemplateState) remember(word string, fill func(*rand.Rand, []byte)) string {
+	state.word = word
+	state.offset = len(state.writer.bytes)
+	state.fill = fill
+	return word
+}
+
+func (appender *tAppender) Write(bytes []byte) (int, error) {
+	appender.bytes = append(appender.bytes, bytes...)
+	return len(bytes), nil
+}
+
+// templateFuncs returns functions available in templates. Functions
+// draw random numbers from generator. Words are remembered in state, to
+// stretch the record.
+func templateFuncs(generator *tGenerator, state *tTemplateState) template.FuncMap {
+	funcs := template.FuncMap{
+		"word": func() string {
+			return state.remember(string(generator.appendWord(nil)), generator.wordFill())
+		},
+		"words": func(words int) string {
+			return state.remember(string(generator.appendWords(nil, words)), generator.wordFill())
+		},
+		"int": func(min, max int) (int, error) {
+			if min <= max {
+				return min + int(randUint64(generator.random, uint64(max)-uint64(min))), nil
+			}
+			return 0, errors.New("int: minimum is greater than maximum")
+		},
+		"float": func(bounds ...float64) (float64, error) {
+			if len(bounds) == 0 {
+				return generator.random.Float64(), nil
+			} else if len(bounds) == 2 && bounds[0] <= bounds[1] {
+				return bounds[0] + generator.random.Float64()*(bounds[1]-bounds[0]), nil
+			}
+			return 0, errors.New("float: expected no arguments or minimum and maximum")
+		},
+		"pick": func(values ...interface{}) (interface{}, error) {
+			if len(values) > 0 {
+				return values[generator.random.Intn(len(values))], nil
+			}
+			return nil, errors.New("pick: no values")
+		},
+		"uuid": func() string {
+			return string(appendUUID(nil, generator.random))
+		},
+		"date": func(layout ...string) string {
+			if len(layout) > 0 {
+				return randomTime(generator.random).Format(layout[0])
+			}
+			return randomTime(generator.random).Format(dateLAYOUT)
+		},
+		"seq": func() int {
+			return int(generator.records)
+		},
+		"charset": func(charset string, length int) (string, error) {
+			if length >= 0 {
+				fill := charsetFill(charset)
+				if strings.ContainsAny(charset, "\r\n") {
+					// new lines of output are replaced
+					return string(generator.appendRandom(nil, length, fill)), nil
+				}
+				return state.remember(string(generator.appendRandom(nil, length, fill)), fill), nil
+			}
+			return "", errors.New("charset: negative length")
+		},
+	}
+	for name, fake := range fakes {
+		fake := fake
+		funcs[name] = func() string {
+			return string(fake(nil, generator.locale(), generator))
+		}
+	}
+	return funcs
+}
+
+// charsetFill returns the fill function of a named charset. Any other name
+// is interpreted as a set of characters.
+func charsetFill(charset string) func(*rand.Rand, []byte) {
+	switch charset {
+	case "alpha":
+		return randomFillA
+	case "lower":
+		return randomFillL
+	case "upper":
+		return randomFillU
+	case "print":
+		return randomFillZ
+	}
+	return func(random *rand.Rand, bytes []byte) {
+		if len(charset) > 0 {
+			for i := range bytes {
+				bytes[i] = charset[random.Intn(len(charset))]
+			}
+		}
+	}
+}
+
+// appendUUID appends a random UUID (version 4).
+func appendUUID(dst []byte, random *rand.Rand) []byte {
+	const hex = "0123456789abcdef"
+	var uuid [16]byte
+	random.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	for i, b := range uuid {
+		if i == 4 || i == 6 || i == 8 || i == 10 {
+			dst = append(dst, '-')
+		}
+		dst = append(dst, hex[b>>4], hex[b&0x0f])
+	}
+	return dst
+}
+
+// randomTime returns a random time between 1970 and 2038.
+func randomTime(random *rand.Rand) time.Time {
+	return time.Unix(dateMIN+random.Int63n(dateMAX-dateMIN), 0).UTC()
+}
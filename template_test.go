@@ -0,0 +1,102 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateRecord(t *testing.T) {
+	var err error
+	format := new(tTemplateFormat)
+	format.template, err = template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{int 3 3}};{{pick \"a\"}};{{charset \"xy\" 4}};{{uuid}}\n")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	content := new(tContent)
+	content.randomFill = randomFillL
+	generator := newGenerator(0, content)
+	record := string(format.record(nil, generator, []byte{'\r', '\n'}))
+	matched, _ := regexp.MatchString("^3;a;[xy]{4};[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\r\n$", record)
+	if !matched {
+		t.Error("wrong record:", record)
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	var err error
+	format := new(tTemplateFormat)
+	format.template, err = template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{word}}{{if gt (int 0 9) 8}}{{int 1 0}}{{end}}\n")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	content := new(tContent)
+	content.randomFill = randomFillL
+	content.format = newRecords(format)
+	generator := newGenerator(1<<16, content)
+	generator.locate(0, len(generator.bytes))
+	generator.generate([]byte{'\n'})
+	if generator.err == nil || generator.writeStd() == nil {
+		t.Error("error of template not returned")
+	}
+}
+
+func TestTemplateSeq(t *testing.T) {
+	var err error
+	format := new(tTemplateFormat)
+	format.template, err = template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{with word}}{{seq}};{{.}}{{end}}\n")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	format.numbered = callsFunc(format.template, "seq")
+	if !format.sequential() || callsFunc(template.Must(template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{word}}{{.}}")), "seq") {
+		t.Error("calls of seq not recognized")
+	}
+	output := generateTest(newRecords(format), 5000, 500, []byte{'\n'})
+	records := 0
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) > 0 && !strings.HasPrefix(line, strconv.Itoa(records)+";") {
+			t.Fatal("not consecutive:", line, records)
+		} else if len(line) > 0 {
+			records++
+		}
+	}
+}
+
+func TestTemplateWithoutWords(t *testing.T) {
+	format := new(tTemplateFormat)
+	format.template = template.Must(template.New("record").Funcs(templateFuncs(nil, nil)).Parse("{{int 1 100}},{{uuid}}\n"))
+	content := &tContent{seed: 1, randomFill: randomFillZ, format: newRecords(format)}
+	if err := checkRecords(content, 1000, []byte{'\n'}, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	record := regexp.MustCompile("^[0-9]{1,3},[0-9a-f-]{36}$")
+	output := generateTest(content.format, 10000, 1000, []byte{'\n'})
+	if len(output) != 10000 {
+		t.Error("wrong size:", len(output))
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) > 0 && !record.MatchString(line) {
+			t.Fatal("wrong record:", line)
+		}
+	}
+}
+
+func TestUUID(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	uuidA := string(appendUUID(nil, random))
+	uuidB := string(appendUUID(nil, random))
+	if len(uuidA) != 36 || uuidA == uuidB {
+		t.Error("wrong UUID:", uuidA, uuidB)
+	}
+}
@@ -40,22 +40,88 @@ type tParameters struct {
 	system     *osargs.Result
 	buffer     *osargs.Result
 	output     *osargs.Result
+	seed       *osargs.Result
+	grammar    *osargs.Result
+	start      *osargs.Result
+	depth      *osargs.Result
+	template   *osargs.Result
+	format     *osargs.Result
+	schema     *osargs.Result
+	fanout     *osargs.Result
+	stress     *osargs.Result
+	tags       *osargs.Result
+	malformed  *osargs.Result
+	mix        *osargs.Result
+	dialect    *osargs.Result
+	batch      *osargs.Result
+	preset     *osargs.Result
+	time       *osargs.Result
+	rate       *osargs.Result
+	locale     *osargs.Result
+	number     *osargs.Result
+	lang       *osargs.Result
+	corrupt    *osargs.Result
+	binary     *osargs.Result
+	weights    *osargs.Result
+	entropy    *osargs.Result
+	encode     *osargs.Result
+	width      *osargs.Result
+	compress   *osargs.Result
+	dupLines   *osargs.Result
+	dupBlocks  *osargs.Result
+	block      *osargs.Result
+	distance   *osargs.Result
+	unique     *osargs.Result
+	sort       *osargs.Result
+	reverse    *osargs.Result
+	lineLength *osargs.Result
+	emptyLines *osargs.Result
+	holes      *osargs.Result
+	sparse     *osargs.Result
+	token      *osargs.Result
+	crypto     *osargs.Result
+	indent     *osargs.Result
+	tabs       *osargs.Result
+	trailing   *osargs.Result
 	infoParams []*osargs.Result
 	cmdParams  []*osargs.Result
 }
 
+type tContent struct {
+	seed       int64
+	randomFill func(*rand.Rand, []byte)
+	letters    bool
+	format     tFormat
+	locale     *tLocale
+}
+
 type tGenerator struct {
 	bytes      []byte
 	random     *rand.Rand
 	randomFill func(*rand.Rand, []byte)
+	content    *tContent
+	offset     int
+	total      int
+	written    int
+	records    int64
+	record     []byte
+	scratch    []byte
+	state      interface{}
+	inner      *tGenerator
+	defects    []tDefect
+	duplicates tDuplicates
+	holes      []tHole
+	stretch    tStretch
+	err        error
+	done       chan bool
 }
 
 type tThreads struct {
-	chnl           chan *tGenerator
+	queue          []*tGenerator
 	counter        int
+	maxThreads     int
 	maxThreadsUsed int
-	seedAdd        int64
-	randomFill     func(*rand.Rand, []byte)
+	content        *tContent
 }
 
 func main() {
@@ -66,22 +132,42 @@ func main() {
 			printInfo(params)
 		} else {
 			var sizeFile, maxThreads, sizeBuffer int
+			content := new(tContent)
 			newLine := interpretNewLine(params)
 			sizeFile, err = interpretSize(params, err)
 			maxThreads, err = interpretThreads(params, err)
 			sizeBuffer, err = interpretBuffer(params, len(newLine)+1, err)
+			content.randomFill, err = interpretTextEntropy(params, randomFillFunc(params), err)
+			content.letters = err == nil && isLetters(alphabetOf(content.randomFill))
+			content.seed, err = interpretSeed(params, err)
+			content.locale, err = interpretLocale(params, err)
+			content.format, err = interpretFormat(params, err)
+			err = initTokens(content, sizeFile, newLine, err)
+			err = initLog(content, err)
+			err = initMail(content, err)
+			content.format, err = interpretIndent(params, content.format, err)
+			content.format, err = interpretUnique(params, content, sizeFile, newLine, err)
+			content.format, err = interpretLongLines(params, content, newLine, err)
+			err = interpretCorrupt(params, content.format, err)
+			err = checkRecords(content, sizeBuffer, newLine, err)
+			err = checkSequential(content, maxThreads, err)
+			content.format, err = interpretCompress(params, content, newLine, err)
+			content.format, err = interpretDuplicate(params, content.format, err)
+			content.format, err = interpretEncode(params, content, err)
+			content.format, err = interpretHoles(params, content.format, err)
 			if err == nil {
+				sizeBuffer = alignBuffer(content.format, sizeBuffer, newLine)
 				if maxThreads == 1 {
 					if params.outputToFile() {
-						err = generateFile(params, sizeFile, sizeBuffer, newLine)
+						err = generateFile(params, content, sizeFile, sizeBuffer, newLine)
 					} else {
-						err = generateStd(params, sizeFile, sizeBuffer, newLine)
+						err = generateStd(content, sizeFile, sizeBuffer, newLine)
 					}
 				} else {
 					if params.outputToFile() {
-						err = generateFileGo(params, sizeFile, sizeBuffer, maxThreads, newLine)
+						err = generateFileGo(params, content, sizeFile, sizeBuffer, maxThreads, newLine)
 					} else {
-						err = generateStdGo(params, sizeFile, sizeBuffer, maxThreads, newLine)
+						err = generateStdGo(content, sizeFile, sizeBuffer, maxThreads, newLine)
 					}
 				}
 			}
@@ -103,6 +189,50 @@ func (params *tParameters) initFromArgs(args *osargs.Arguments) error {
 	var err error
 	if len(args.Values) > 0 {
 		delimiter := osargs.NewDelimiter(true, true, "=")
+		// long names first, otherwise short flags would match their prefix
+		params.seed = args.ParsePairs(delimiter, "--seed", "-seed")
+		params.grammar = args.ParsePairs(delimiter, "--grammar", "-grammar")
+		params.start = args.ParsePairs(delimiter, "--start", "-start")
+		params.depth = args.ParsePairs(delimiter, "--depth", "-depth")
+		params.template = args.ParsePairs(delimiter, "--template", "-template")
+		params.format = args.ParsePairs(delimiter, "--format", "-format")
+		params.schema = args.ParsePairs(delimiter, "--schema", "-schema")
+		params.fanout = args.ParsePairs(delimiter, "--fanout", "-fanout")
+		params.stress = args.ParsePairs(delimiter, "--stress", "-stress")
+		params.tags = args.ParsePairs(delimiter, "--tags", "-tags")
+		params.malformed = args.ParsePairs(delimiter, "--malformed", "-malformed")
+		params.mix = args.ParsePairs(delimiter, "--mix", "-mix")
+		params.dialect = args.ParsePairs(delimiter, "--dialect", "-dialect")
+		params.batch = args.ParsePairs(delimiter, "--batch", "-batch")
+		params.preset = args.ParsePairs(delimiter, "--preset", "-preset")
+		params.time = args.ParsePairs(delimiter, "--time", "-time")
+		params.rate = args.ParsePairs(delimiter, "--rate", "-rate")
+		params.locale = args.ParsePairs(delimiter, "--locale", "-locale")
+		params.number = args.ParsePairs(delimiter, "--number", "-number")
+		params.lang = args.ParsePairs(delimiter, "--lang", "-lang")
+		params.corrupt = args.ParsePairs(delimiter, "--corrupt", "-corrupt")
+		params.weights = args.ParsePairs(delimiter, "--weights", "-weights")
+		params.entropy = args.ParsePairs(delimiter, "--entropy", "-entropy")
+		params.encode = args.ParsePairs(delimiter, "--encode", "-encode")
+		params.width = args.ParsePairs(delimiter, "--width", "-width")
+		params.compress = args.ParsePairs(delimiter, "--compress-ratio", "-compress-ratio")
+		params.dupLines = args.ParsePairs(delimiter, "--dup-lines", "-dup-lines")
+		params.dupBlocks = args.ParsePairs(delimiter, "--dup-blocks", "-dup-blocks")
+		params.block = args.ParsePairs(delimiter, "--block", "-block")
+		params.distance = args.ParsePairs(delimiter, "--distance", "-distance")
+		params.sort = args.ParsePairs(delimiter, "--sort", "-sort")
+		params.lineLength = args.ParsePairs(delimiter, "--line-length", "-line-length")
+		params.emptyLines = args.ParsePairs(delimiter, "--empty-lines", "-empty-lines")
+		params.holes = args.ParsePairs(delimiter, "--holes", "-holes")
+		params.token = args.ParsePairs(delimiter, "--token", "-token")
+		params.indent = args.ParsePairs(delimiter, "--indent", "-indent")
+		params.tabs = args.ParsePairs(delimiter, "--tabs", "-tabs")
+		params.trailing = args.ParsePairs(delimiter, "--trailing", "-trailing")
+		params.binary = args.Parse("--binary", "-binary")
+		params.unique = args.Parse("--unique", "-unique")
+		params.reverse = args.Parse("--reverse", "-reverse")
+		params.sparse = args.Parse("--sparse", "-sparse")
+		params.crypto = args.Parse("--crypto", "-crypto")
 		params.help = args.Parse("-h", "--help", "-help", "help")
 		params.version = args.Parse("-v", "--version", "-version", "version")
 		params.example = args.Parse("-e", "--example", "-example", "example")
@@ -217,7 +347,7 @@ func (params *tParameters) poolInfoParams() {
 }
 
 func (params *tParameters) poolCmdParams() {
-	params.cmdParams = make([]*osargs.Result, 8)
+	params.cmdParams = make([]*osargs.Result, 51)
 	params.cmdParams[0] = params.size
 	params.cmdParams[1] = params.threads
 	params.cmdParams[2] = params.system
@@ -226,6 +356,49 @@ func (params *tParameters) poolCmdParams() {
 	params.cmdParams[5] = params.alpha
 	params.cmdParams[6] = params.lower
 	params.cmdParams[7] = params.upper
+	params.cmdParams[8] = params.seed
+	params.cmdParams[9] = params.grammar
+	params.cmdParams[10] = params.start
+	params.cmdParams[11] = params.depth
+	params.cmdParams[12] = params.template
+	params.cmdParams[13] = params.format
+	params.cmdParams[14] = params.schema
+	params.cmdParams[15] = params.fanout
+	params.cmdParams[16] = params.stress
+	params.cmdParams[17] = params.tags
+	params.cmdParams[18] = params.malformed
+	params.cmdParams[19] = params.mix
+	params.cmdParams[20] = params.dialect
+	params.cmdParams[21] = params.batch
+	params.cmdParams[22] = params.preset
+	params.cmdParams[23] = params.time
+	params.cmdParams[24] = params.rate
+	params.cmdParams[25] = params.locale
+	params.cmdParams[26] = params.number
+	params.cmdParams[27] = params.lang
+	params.cmdParams[28] = params.corrupt
+	params.cmdParams[29] = params.binary
+	params.cmdParams[30] = params.weights
+	params.cmdParams[31] = params.entropy
+	params.cmdParams[32] = params.encode
+	params.cmdParams[33] = params.width
+	params.cmdParams[34] = params.compress
+	params.cmdParams[35] = params.dupLines
+	params.cmdParams[36] = params.dupBlocks
+	params.cmdParams[37] = params.block
+	params.cmdParams[38] = params.distance
+	params.cmdParams[39] = params.unique
+	params.cmdParams[40] = params.sort
+	params.cmdParams[41] = params.reverse
+	params.cmdParams[42] = params.lineLength
+	params.cmdParams[43] = params.emptyLines
+	params.cmdParams[44] = params.holes
+	params.cmdParams[45] = params.sparse
+	params.cmdParams[46] = params.token
+	params.cmdParams[47] = params.crypto
+	params.cmdParams[48] = params.indent
+	params.cmdParams[49] = params.tabs
+	params.cmdParams[50] = params.trailing
 }
 
 func (params *tParameters) infoAvailable() bool {
@@ -356,144 +529,368 @@ func interpretBuffer(params *tParameters, sizeFile int, err error) (int, error)
 	return 0, err
 }
 
-func generateFile(params *tParameters, sizeFile, sizeBuffer int, newLine []byte) error {
+func interpretSeed(params *tParameters, err error) (int64, error) {
+	if err == nil {
+		if params.seed.Available() {
+			seed, err := strconv.ParseInt(params.seed.Values[0], 10, 64)
+			if err == nil {
+				return seed, nil
+			}
+			return 0, errors.New("can't parse seed")
+		}
+		return time.Now().UnixNano(), nil
+	}
+	return 0, err
+}
+
+func interpretDepth(params *tParameters, depthDefault int, err error) (int, error) {
+	if err == nil {
+		if params.depth.Available() {
+			depth, err := strconv.Atoi(params.depth.Values[0])
+			if err == nil && depth > 0 {
+				return depth, nil
+			}
+			return 0, errors.New("can't parse depth")
+		}
+		return depthDefault, nil
+	}
+	return 0, err
+}
+
+func interpretFanout(params *tParameters, fanoutDefault int, err error) (int, error) {
+	if err == nil {
+		if params.fanout.Available() {
+			fanout, err := strconv.Atoi(params.fanout.Values[0])
+			if err == nil && fanout > 0 {
+				return fanout, nil
+			}
+			return 0, errors.New("can't parse fanout")
+		}
+		return fanoutDefault, nil
+	}
+	return 0, err
+}
+
+// interpretRate returns a probability, i.e. a number in [0, 1].
+func interpretRate(param *osargs.Result, name string, err error) (float64, error) {
+	if err == nil {
+		if param.Available() {
+			rate, err := strconv.ParseFloat(param.Values[0], 64)
+			if err == nil && rate >= 0 && rate <= 1 {
+				return rate, nil
+			}
+			return 0, errors.New("can't parse " + name + " rate")
+		}
+		return 0, nil
+	}
+	return 0, err
+}
+
+func interpretFormat(params *tParameters, err error) (tFormat, error) {
+	if err == nil {
+		if params.binary.Available() {
+			if params.format.Available() || params.grammar.Available() || params.template.Available() {
+				return nil, errors.New("binary, grammar, template and format are exclusive")
+			}
+			return interpretBinary(params)
+		} else if params.weights.Available() {
+			return nil, errors.New("weights need binary")
+		} else if params.crypto.Available() && (!params.format.Available() || strings.ToLower(params.format.Values[0]) != "tokens") {
+			return nil, errors.New("crypto needs format tokens")
+		} else if params.grammar.Available() || params.template.Available() {
+			if params.format.Available() || params.grammar.Available() && params.template.Available() {
+				return nil, errors.New("grammar, template and format are exclusive")
+			} else if params.grammar.Available() {
+				return interpretGrammar(params)
+			}
+			return interpretTemplate(params)
+		} else if params.stress.Available() {
+			if !params.format.Available() || strings.ToLower(params.format.Values[0]) == "json" {
+				return interpretStress(params, false)
+			} else if strings.ToLower(params.format.Values[0]) == "xml" {
+				return interpretStress(params, true)
+			}
+			return nil, errors.New("stress tests are available for json and xml, only")
+		} else if params.format.Available() {
+			switch strings.ToLower(params.format.Values[0]) {
+			case "text":
+				return new(tText), nil
+			case "csv":
+				return interpretCSV(params, ",")
+			case "tsv":
+				return interpretCSV(params, "\t")
+			case "json":
+				return interpretJSON(params, false)
+			case "jsonl":
+				return interpretJSON(params, true)
+			case "xml":
+				return interpretMarkup(params, false)
+			case "html":
+				return interpretMarkup(params, true)
+			case "markdown", "md":
+				return interpretMarkdown(params)
+			case "sql":
+				return interpretSQL(params)
+			case "yaml", "yml":
+				return interpretConfig(params, "yaml")
+			case "toml":
+				return interpretConfig(params, "toml")
+			case "ini":
+				return interpretConfig(params, "ini")
+			case "log":
+				return interpretLog(params)
+			case "numbers":
+				return interpretNumbers(params)
+			case "tokens":
+				return interpretTokens(params)
+			case "fixed":
+				return interpretFixed(params)
+			case "code":
+				return interpretCode(params)
+			case "mbox":
+				return interpretMail(params, true)
+			case "eml":
+				return interpretMail(params, false)
+			}
+			return nil, errors.New("unknown format \"" + params.format.Values[0] + "\"")
+		}
+		return new(tText), nil
+	}
+	return nil, err
+}
+
+func generateFile(params *tParameters, content *tContent, sizeFile, sizeBuffer int, newLine []byte) error {
 	pathOut := params.output.Values[0]
 	out, err := os.OpenFile(pathOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err == nil {
 		defer out.Close()
+		var report *os.File
+		report, err = createReport(params)
+		if report != nil {
+			defer report.Close()
+		}
+		var duplicates tDuplicates
 		timeStart := time.Now().UnixNano()
-		randomFill := randomFillFunc(params)
-		generator := newGenerator(sizeBuffer, 0, randomFill)
+		generator := newGenerator(sizeBuffer, content)
 		for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile && err == nil; sizeTotal += sizeAdd {
 			sizeAdd = generator.adjustBuffer(sizeFile - sizeTotal)
-			generator.generateText(newLine)
+			generator.locate(sizeTotal, sizeFile)
+			generator.generate(newLine)
 			err = generator.writeFile(out)
+			if err == nil {
+				err = generator.writeReport(report)
+				duplicates.add(&generator.duplicates)
+			}
 		}
 		timeEnd := time.Now().UnixNano()
 		printThreadsUsed(1)
 		printTime(timeEnd - timeStart)
+		printDuplicates(params, &duplicates)
 	}
 	return err
 }
 
-func generateStd(params *tParameters, sizeFile, sizeBuffer int, newLine []byte) error {
-	randomFill := randomFillFunc(params)
-	generator := newGenerator(sizeBuffer, 0, randomFill)
-	for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile; sizeTotal += sizeAdd {
+func generateStd(content *tContent, sizeFile, sizeBuffer int, newLine []byte) error {
+	var err error
+	generator := newGenerator(sizeBuffer, content)
+	for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile && err == nil; sizeTotal += sizeAdd {
 		sizeAdd = generator.adjustBuffer(sizeFile - sizeTotal)
-		generator.generateText(newLine)
-		generator.writeStd()
+		generator.locate(sizeTotal, sizeFile)
+		generator.generate(newLine)
+		err = generator.writeStd()
 	}
-	return nil
+	return err
 }
 
-func generateFileGo(params *tParameters, sizeFile, sizeBuffer int, maxThreads int, newLine []byte) error {
+func generateFileGo(params *tParameters, content *tContent, sizeFile, sizeBuffer int, maxThreads int, newLine []byte) error {
 	pathOut := params.output.Values[0]
 	out, err := os.OpenFile(pathOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err == nil {
 		defer out.Close()
+		var report *os.File
+		report, err = createReport(params)
+		if report != nil {
+			defer report.Close()
+		}
+		var duplicates tDuplicates
 		timeStart := time.Now().UnixNano()
-		randomFill := randomFillFunc(params)
-		threads := newThreads(maxThreads, randomFill)
+		threads := newThreads(maxThreads, content)
 		for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile && err == nil; sizeTotal += sizeAdd {
 			generator, result := threads.nextGenerator(sizeBuffer)
 			if result {
 				sizeAdd = 0
 				err = generator.writeFile(out)
+				if err == nil {
+					err = generator.writeReport(report)
+					duplicates.add(&generator.duplicates)
+				}
 			} else {
 				sizeAdd = generator.adjustBuffer(sizeFile - sizeTotal)
-				go threads.generateTextGo(generator, newLine)
+				generator.locate(sizeTotal, sizeFile)
+				go threads.generateGo(generator, newLine)
 			}
 		}
 		for threads.counter > 0 && err == nil {
 			generator := threads.nextGeneratorResult(sizeBuffer)
 			err = generator.writeFile(out)
+			if err == nil {
+				err = generator.writeReport(report)
+				duplicates.add(&generator.duplicates)
+			}
 		}
 		timeEnd := time.Now().UnixNano()
 		printThreadsUsed(threads.maxThreadsUsed)
 		printTime(timeEnd - timeStart)
+		printDuplicates(params, &duplicates)
 	}
 	return err
 }
 
-func generateStdGo(params *tParameters, sizeFile, sizeBuffer int, maxThreads int, newLine []byte) error {
-	randomFill := randomFillFunc(params)
-	threads := newThreads(maxThreads, randomFill)
-	for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile; sizeTotal += sizeAdd {
+func generateStdGo(content *tContent, sizeFile, sizeBuffer int, maxThreads int, newLine []byte) error {
+	var err error
+	threads := newThreads(maxThreads, content)
+	for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile && err == nil; sizeTotal += sizeAdd {
 		generator, result := threads.nextGenerator(sizeBuffer)
 		if result {
 			sizeAdd = 0
-			generator.writeStd()
+			err = generator.writeStd()
 		} else {
 			sizeAdd = generator.adjustBuffer(sizeFile - sizeTotal)
-			go threads.generateTextGo(generator, newLine)
+			generator.locate(sizeTotal, sizeFile)
+			go threads.generateGo(generator, newLine)
 		}
 	}
-	for threads.counter > 0 {
+	for threads.counter > 0 && err == nil {
 		generator := threads.nextGeneratorResult(sizeBuffer)
-		generator.writeStd()
+		err = generator.writeStd()
 	}
-	return nil
+	return err
 }
 
-func newThreads(maxThreads int, randomFill func(*rand.Rand, []byte)) *tThreads {
+func newThreads(maxThreads int, content *tContent) *tThreads {
 	threads := new(tThreads)
-	threads.chnl = make(chan *tGenerator, maxThreads)
-	threads.randomFill = randomFill
+	threads.maxThreads = maxThreads
+	threads.content = content
 	return threads
 }
 
+// nextGenerator returns generators in the order they have been created,
+// so that buffers are written in the same order as they are located.
 func (threads *tThreads) nextGenerator(sizeBuffer int) (*tGenerator, bool) {
-	if threads.counter < cap(threads.chnl) {
-		select {
-		case generator := <-threads.chnl:
-			threads.counter--
-			return generator, true
-		default:
-			threads.counter++
-			threads.seedAdd++
-			if threads.maxThreadsUsed < threads.counter {
-				threads.maxThreadsUsed = threads.counter
+	if threads.counter < threads.maxThreads {
+		if threads.counter > 0 {
+			select {
+			case <-threads.queue[0].done:
+				return threads.dequeue(), true
+			default:
 			}
 		}
-		return newGenerator(sizeBuffer, threads.seedAdd, threads.randomFill), false
+		generator := newGenerator(sizeBuffer, threads.content)
+		generator.done = make(chan bool, 1)
+		threads.queue = append(threads.queue, generator)
+		threads.counter++
+		if threads.maxThreadsUsed < threads.counter {
+			threads.maxThreadsUsed = threads.counter
+		}
+		return generator, false
 	}
 	return threads.nextGeneratorResult(sizeBuffer), true
 }
 
 func (threads *tThreads) nextGeneratorResult(sizeBuffer int) *tGenerator {
-	generator := <-threads.chnl
+	<-threads.queue[0].done
+	return threads.dequeue()
+}
+
+func (threads *tThreads) dequeue() *tGenerator {
+	generator := threads.queue[0]
+	threads.queue[0] = nil
+	threads.queue = threads.queue[1:]
 	threads.counter--
 	return generator
 }
 
-func (threads *tThreads) generateTextGo(generator *tGenerator, newLine []byte) {
-	generator.generateText(newLine)
-	threads.chnl <- generator
+func (threads *tThreads) generateGo(generator *tGenerator, newLine []byte) {
+	generator.generate(newLine)
+	generator.done <- true
 }
 
-func newGenerator(sizeBuffer int, seedAdd int64, randomFill func(*rand.Rand, []byte)) *tGenerator {
+func newGenerator(sizeBuffer int, content *tContent) *tGenerator {
 	generator := new(tGenerator)
 	generator.bytes = make([]byte, sizeBuffer)
-	generator.random = rand.New(rand.NewSource(time.Now().UnixNano() + seedAdd))
-	generator.randomFill = randomFill
+	generator.random = rand.New(rand.NewSource(content.seed))
+	generator.randomFill = content.randomFill
+	generator.content = content
 	return generator
 }
 
+// locate sets the position of the buffer in output. Random numbers depend
+// on seed and position only, so output doesn't depend on number of threads.
+// Both are hashed, so buffers of different seeds don't share numbers.
+func (generator *tGenerator) locate(offset, total int) {
+	random := tSplitMix{uint64(generator.content.seed)}
+	random.state = random.next() + uint64(offset)
+	generator.offset = offset
+	generator.total = total
+	generator.written = 0
+	if offset == 0 {
+		generator.records = 0
+	}
+	generator.state = nil
+	generator.defects = generator.defects[:0]
+	generator.err = nil
+	generator.random.Seed(int64(random.next()))
+}
+
+// first returns true, if buffer is at the beginning of output.
+func (generator *tGenerator) first() bool {
+	return generator.offset == 0
+}
+
+// last returns true, if buffer is at the end of output.
+func (generator *tGenerator) last() bool {
+	return generator.offset+len(generator.bytes) >= generator.total
+}
+
+// position returns the position in output, that follows the written bytes.
+func (generator *tGenerator) position() int {
+	return generator.offset + generator.written
+}
+
+func (generator *tGenerator) generate(newLine []byte) {
+	generator.content.format.fill(generator, newLine)
+}
+
+// adjustBuffer sets size of buffer to the remaining size of output, if
+// the remainder is smaller than two buffers, i.e. the last buffer is never
+// too small for records.
 func (generator *tGenerator) adjustBuffer(sizeRemaining int) int {
-	if sizeRemaining < len(generator.bytes) {
+	if sizeRemaining < 2*len(generator.bytes) {
+		if sizeRemaining > cap(generator.bytes) {
+			generator.bytes = append(generator.bytes, make([]byte, sizeRemaining-len(generator.bytes))...)
+		}
 		generator.bytes = generator.bytes[:sizeRemaining]
 	}
 	return len(generator.bytes)
 }
 
+// writeFile writes buffer to file. Error of generation is returned, if
+// any, i.e. the buffer isn't written.
 func (generator *tGenerator) writeFile(out *os.File) error {
+	if generator.err != nil {
+		return generator.err
+	} else if len(generator.holes) > 0 {
+		return generator.writeSparse(out)
+	}
 	_, err := out.Write(generator.bytes)
 	return err
 }
 
-func (generator *tGenerator) writeStd() {
-	fmt.Printf("%s", generator.bytes)
+func (generator *tGenerator) writeStd() error {
+	if generator.err == nil {
+		fmt.Printf("%s", generator.bytes)
+	}
+	return generator.err
 }
 
 func (generator *tGenerator) generateText(newLine []byte) {
@@ -698,7 +1095,53 @@ func printHelp() {
 	message += "  -b=N[U]          buffer size per thread, U = unit (k/K, m/M or g/G)\n"
 	message += "  -a               output letters, only\n"
 	message += "  -l               output lower case letters, only\n"
-	message += "  -u               output upper case letters, only"
+	message += "  -u               output upper case letters, only\n"
+	message += "  --seed=N         seed for random numbers (reproducible output)\n"
+	message += "  --grammar=F      generate sentences of EBNF/BNF grammar in file F\n"
+	message += "  --start=S        start symbol of grammar (default first rule)\n"
+	message += "  --depth=N        maximum depth of nesting\n"
+	message += "  --template=F     generate records with Go template in file F\n"
+	message += "  --format=F       output format: text (default), csv, tsv, json, jsonl, xml, html, markdown, sql,\n"
+	message += "                   yaml, toml, ini, log, numbers, fixed, code, mbox, eml, tokens\n"
+	message += "  --schema=F       JSON file with columns of records (layout of fixed records)\n"
+	message += "  --fanout=N       maximum number of children, e.g. elements of arrays\n"
+	message += "  --stress=S       JSON/XML parser stress test: depth, string, array, escape or number\n"
+	message += "  --tags=T,...     element names of XML/HTML\n"
+	message += "  --malformed=R    rate of malformed XML/HTML elements (e.g. 0.01)\n"
+	message += "  --mix=E:W,...    weights of elements, e.g. --mix=paragraph:5,table:1\n"
+	message += "  --dialect=D      SQL dialect: postgres (default), mysql or sqlite\n"
+	message += "  --batch=N        rows per INSERT statement (default 100)\n"
+	message += "  --preset=P       log format: combined (default), syslog, rfc5424, logfmt or json\n"
+	message += "  --time=T         time of first log line or mail (e.g. 2022-01-01T12:00:00Z)\n"
+	message += "  --rate=N         log lines per second (default 100), mails per second (default 0.01)\n"
+	message += "  --locale=L       locale of fake personal data: en (default), de or fr\n"
+	message += "  --number=T:K=V.. number of --format=numbers, e.g. float:min=0:max=1\n"
+	message += "  --lang=L         language of --format=code: c (default), go or js\n"
+	message += "  --corrupt=R      rate of records with defects, offsets are written to OUTPUT.defects\n"
+	message += "  --binary         output random bytes of all 256 values\n"
+	message += "  --weights=B:W,.. weights of bytes of --binary, e.g. 0-31:1,65:10\n"
+	message += "  --entropy=N      Shannon entropy in bits per byte (--binary) or per character of words\n"
+	message += "  --encode=E       encode output: base64, base32, hex or ascii85 (size is encoded size)\n"
+	message += "  --width=N        characters per line of --encode (0 is one line)\n"
+	message += "  --compress-ratio=R ratio of deflate compression of text or binary output (e.g. 0.4)\n"
+	message += "  --dup-lines=R    rate of lines of text, that repeat earlier lines (e.g. 0.3)\n"
+	message += "  --dup-blocks=R   rate of blocks of text or binary, that repeat earlier blocks\n"
+	message += "  --block=N[U]     size of blocks of --dup-blocks (default 4K)\n"
+	message += "  --distance=D:N   distance of repeats in lines or blocks: geometric:N (mean, default 100)\n"
+	message += "                   or uniform:N (1 to N)\n"
+	message += "  --unique         lines of text start with unique keys\n"
+	message += "  --sort=S         unique lines sorted by keys: bytes, fold (case-insensitive) or natural\n"
+	message += "  --reverse        lines of --sort in reverse order\n"
+	message += "  --line-length=N[U] exact length of lines of text, e.g. 100M (0 is one line)\n"
+	message += "  --empty-lines=R  rate of empty lines between lines of --line-length (e.g. 0.9)\n"
+	message += "  --holes=O:S[:P],.. runs of NUL bytes at offset O of size S, repeated every P bytes\n"
+	message += "  --sparse         holes are sparse holes of output file (skipped by seek)\n"
+	message += "  --token=T:K=V..  token of --format=tokens: password, apikey, hex or base58,\n"
+	message += "                   e.g. password:length=20:classes=ulds or apikey:prefix=sk_\n"
+	message += "  --crypto         random numbers of tokens from crypto/rand (not reproducible)\n"
+	message += "  --indent=I[:N]   indentation of lines of text: tabs, spaces or mixed (N spaces, default 4)\n"
+	message += "  --tabs=R         rate of tabs between words of text (e.g. 0.1)\n"
+	message += "  --trailing=R     rate of lines of text with trailing spaces and tabs"
 	fmt.Println(message)
 }
 
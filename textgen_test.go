@@ -102,3 +102,28 @@ func TestParseOSArgsC(t *testing.T) {
 		t.Error("valid parameter not recognized: " + err.Error())
 	}
 }
+
+// generateTest returns output of format generated in buffers of size sizeBuffer.
+func generateTest(format tFormat, sizeFile, sizeBuffer int, newLine []byte) []byte {
+	return generateTestBuffers(format, sizeFile, sizeBuffer, newLine, func(*tGenerator) {})
+}
+
+// generateTestBuffers returns output of format generated in buffers of size
+// sizeBuffer and passes the generator of each buffer to generated, e.g. to
+// collect defects or duplicates.
+func generateTestBuffers(format tFormat, sizeFile, sizeBuffer int, newLine []byte, generated func(*tGenerator)) []byte {
+	var output []byte
+	content := new(tContent)
+	content.randomFill = randomFillZ
+	content.seed = 1
+	content.format = format
+	generator := newGenerator(sizeBuffer, content)
+	for sizeTotal, sizeAdd := 0, 0; sizeTotal < sizeFile; sizeTotal += sizeAdd {
+		sizeAdd = generator.adjustBuffer(sizeFile - sizeTotal)
+		generator.locate(sizeTotal, sizeFile)
+		generator.generate(newLine)
+		output = append(output, generator.bytes...)
+		generated(generator)
+	}
+	return output
+}
@@ -0,0 +1,308 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	tokenPASSWORD = "password"
+	tokenAPIKEY   = "apikey"
+	tokenHEX      = "hex"
+	tokenBASE58   = "base58"
+)
+
+const (
+	tokenKIND_DEFAULT   = tokenPASSWORD
+	tokenPREFIX_DEFAULT = "tg_"
+	tokenCRYPTO_BUFFER  = 4096
+)
+
+var tokenLengths = map[string]int{tokenPASSWORD: 16, tokenAPIKEY: 32, tokenHEX: 32, tokenBASE58: 22}
+
+// tTokenFormat writes one token per line. A token has prefix and length
+// characters. Passwords have at least one character of each class.
+//
+// Tokens encode a permutation of their line number, so they are unique. If
+// the number doesn't need all characters, the rest is random. Tokens of
+// crypto/rand are random, only. Buffers are aligned to lines, because
+// tokens of crypto/rand can't be split. Space at the end of output, that is
+// too small for a token, is filled with blank lines.
+type tTokenFormat struct {
+	prefix   string
+	length   int
+	classes  [][]byte
+	alphabet []byte
+	crypto   bool
+	digits   int
+	lines    tPermutation
+	seed     uint64
+}
+
+// tCryptoState buffers random bytes of crypto/rand.
+type tCryptoState struct {
+	bytes  []byte
+	offset int
+}
+
+// interpretTokens returns format of one token per line. The token is
+// described by --token, e.g. "password:length=20:classes=ulds".
+func interpretTokens(params *tParameters) (tFormat, error) {
+	var err error
+	spec := tokenKIND_DEFAULT
+	if params.token.Available() {
+		spec = params.token.Values[0]
+	}
+	properties := strings.Split(spec, ":")
+	kind := strings.ToLower(strings.TrimSpace(properties[0]))
+	length, ok := tokenLengths[kind]
+	if !ok {
+		return nil, errors.New("unknown token \"" + properties[0] + "\" (expected password, apikey, hex or base58)")
+	}
+	format := &tTokenFormat{length: length, crypto: params.crypto.Available()}
+	classes := tokenClassesDefault(params)
+	if kind == tokenAPIKEY {
+		format.prefix = tokenPREFIX_DEFAULT
+	}
+	for _, property := range properties[1:] {
+		keyValue := strings.SplitN(property, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, errors.New("token, expected key=value, got \"" + property + "\"")
+		}
+		switch keyValue[0] {
+		case "length":
+			format.length, err = strconv.Atoi(keyValue[1])
+			if err == nil && format.length <= 0 {
+				err = errors.New("length")
+			}
+		case "prefix":
+			format.prefix = keyValue[1]
+		case "classes":
+			if kind != tokenPASSWORD {
+				return nil, errors.New("token, classes need password")
+			}
+			classes = keyValue[1]
+		default:
+			return nil, errors.New("token, unknown property \"" + keyValue[0] + "\"")
+		}
+		if err != nil {
+			return nil, errors.New("token, can't parse " + keyValue[0])
+		}
+	}
+	switch kind {
+	case tokenPASSWORD:
+		err = format.initClasses(classes)
+	case tokenAPIKEY:
+		format.alphabet = tokenAlphabet("uld")
+	case tokenHEX:
+		format.alphabet = []byte("0123456789abcdef")
+	case tokenBASE58:
+		format.alphabet = []byte("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+	}
+	if err == nil {
+		return format, nil
+	}
+	return nil, err
+}
+
+// initTokens sets the permutation of line numbers of tokens. Error is
+// returned, if there are less tokens than lines in output.
+func initTokens(content *tContent, sizeFile int, newLine []byte, err error) error {
+	if format, ok := content.format.(*tTokenFormat); ok && err == nil && !format.crypto {
+		lines := sizeFile / format.alignment(newLine)
+		capacity := uint64(1)
+		for format.digits = 0; format.digits < format.length; format.digits++ {
+			base := uint64(len(format.alphabetAt(format.digits)))
+			if capacity > math.MaxUint64/base {
+				break
+			}
+			capacity *= base
+		}
+		if uint64(lines) > capacity {
+			return errors.New("token, " + strconv.FormatUint(capacity, 10) + " tokens are less than " + strconv.Itoa(lines) + " lines (increase length)")
+		}
+		format.seed = uint64(content.seed)
+		format.lines = newPermutation(rand.New(rand.NewSource(content.seed)), capacity)
+	}
+	return err
+}
+
+// tokenClassesDefault returns the classes of characters of -a, -l and -u,
+// or all classes.
+func tokenClassesDefault(params *tParameters) string {
+	var classes string
+	if params.alpha.Available() || params.upper.Available() {
+		classes += "u"
+	}
+	if params.alpha.Available() || params.lower.Available() {
+		classes += "l"
+	}
+	if len(classes) > 0 {
+		return classes
+	}
+	return "ulds"
+}
+
+// initClasses sets the alphabets of classes, i.e. u (upper case), l (lower
+// case), d (digits) or s (symbols), and their union.
+func (format *tTokenFormat) initClasses(classes string) error {
+	format.classes = nil
+	for _, class := range classes {
+		alphabet := tokenAlphabet(string(class))
+		if len(alphabet) == 0 {
+			return errors.New("token, unknown class \"" + string(class) + "\" (expected u, l, d or s)")
+		}
+		format.classes = append(format.classes, alphabet)
+		format.alphabet = append(format.alphabet, alphabet...)
+	}
+	if len(format.classes) == 0 || len(format.classes) > format.length {
+		return errors.New("token, length is less than number of classes")
+	}
+	return nil
+}
+
+// tokenAlphabet returns characters of classes. Letters are those of -u and
+// -l, digits and symbols are the rest of default characters of text.
+func tokenAlphabet(classes string) []byte {
+	var alphabet []byte
+	for _, class := range classes {
+		switch class {
+		case 'u':
+			alphabet = append(alphabet, alphabetOf(randomFillU)...)
+		case 'l':
+			alphabet = append(alphabet, alphabetOf(randomFillL)...)
+		case 'd', 's':
+			for _, b := range alphabetOf(randomFillZ) {
+				if isDigit(b) == (class == 'd') && !(b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z') {
+					alphabet = append(alphabet, b)
+				}
+			}
+		}
+	}
+	return alphabet
+}
+
+// alignment returns length of line.
+func (format *tTokenFormat) alignment(newLine []byte) int {
+	return len(format.prefix) + format.length + len(newLine)
+}
+
+// alphabetAt returns the characters of token at index.
+func (format *tTokenFormat) alphabetAt(index int) []byte {
+	if index < len(format.classes) {
+		return format.classes[index]
+	}
+	return format.alphabet
+}
+
+func (format *tTokenFormat) fill(generator *tGenerator, newLine []byte) {
+	length := format.alignment(newLine)
+	line, from := generator.offset/length, generator.offset%length
+	for generator.written < len(generator.bytes) && generator.err == nil {
+		if generator.position()+length-from > generator.total {
+			// no partial token at the end of output
+			fillBlank(generator.bytes[generator.written:], newLine)
+			generator.written = len(generator.bytes)
+			break
+		}
+		generator.record = format.appendToken(generator.record[:0], generator, uint64(line), newLine)
+		generator.written += copy(generator.bytes[generator.written:], generator.record[from:])
+		line, from = line+1, 0
+	}
+}
+
+func (format *tTokenFormat) appendToken(dst []byte, generator *tGenerator, line uint64, newLine []byte) []byte {
+	dst = append(dst, format.prefix...)
+	token := len(dst)
+	if format.crypto {
+		for i := 0; i < format.length; i++ {
+			alphabet := format.alphabetAt(i)
+			dst = append(dst, alphabet[cryptoIntn(generator, len(alphabet))])
+		}
+		if len(format.classes) > 0 {
+			// required classes at random positions
+			for i := len(dst) - 1; i > token; i-- {
+				j := token + cryptoIntn(generator, i-token+1)
+				dst[i], dst[j] = dst[j], dst[i]
+			}
+		}
+	} else {
+		value := format.lines.permute(line)
+		value = format.lines.permute(format.reverseDigits(value))
+		random := tSplitMix{format.seed ^ line*0x9e3779b97f4a7c15}
+		for i := 0; i < format.length; i++ {
+			alphabet := format.alphabetAt(i)
+			base := uint64(len(alphabet))
+			if i < format.digits {
+				dst = append(dst, alphabet[value%base])
+				value /= base
+			} else {
+				dst = append(dst, alphabet[random.next()%base])
+			}
+		}
+		if len(format.classes) > 0 {
+			// required classes at random positions, that depend on the
+			// characters only, so shuffled tokens remain unique
+			var hash uint64
+			for _, b := range dst[token:] {
+				mix := tSplitMix{format.seed + uint64(b)}
+				hash += mix.next()
+			}
+			random.state = hash
+			for i := len(dst) - 1; i > token; i-- {
+				j := token + int(random.next()%uint64(i-token+1))
+				dst[i], dst[j] = dst[j], dst[i]
+			}
+		}
+	}
+	return append(dst, newLine...)
+}
+
+// reverseDigits returns value with digits of the characters, that encode
+// the line number, in reverse order.
+func (format *tTokenFormat) reverseDigits(value uint64) uint64 {
+	var reversed uint64
+	for i := 0; i < format.digits; i++ {
+		base := uint64(len(format.alphabetAt(i)))
+		reversed = reversed*base + value%base
+		value /= base
+	}
+	return reversed
+}
+
+// cryptoIntn returns a random number in [0, n) of crypto/rand. Error of
+// crypto/rand is set in generator.
+func cryptoIntn(generator *tGenerator, n int) int {
+	state, ok := generator.state.(*tCryptoState)
+	if !ok {
+		state = &tCryptoState{bytes: make([]byte, tokenCRYPTO_BUFFER), offset: tokenCRYPTO_BUFFER}
+		generator.state = state
+	}
+	// rejection of values above the largest multiple of n
+	limit := 1<<16 - 1<<16%n
+	for {
+		if state.offset+2 > len(state.bytes) {
+			if _, err := crand.Read(state.bytes); err != nil {
+				generator.err = err
+				return 0
+			}
+			state.offset = 0
+		}
+		value := int(state.bytes[state.offset])<<8 | int(state.bytes[state.offset+1])
+		state.offset += 2
+		if value < limit {
+			return value % n
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTokenPassword(t *testing.T) {
+	format := &tTokenFormat{length: 8}
+	if err := format.initClasses("ulds"); err != nil {
+		t.Fatal(err.Error())
+	}
+	output := generateTokensTest(t, format, 9*10000, []byte{'\n'})
+	tokens := make(map[string]bool)
+	lines := bytes.Fields(output)
+	for _, line := range lines {
+		if len(line) != 8 {
+			t.Fatal("wrong length:", string(line))
+		}
+		for _, class := range format.classes {
+			if !bytes.ContainsAny(line, string(class)) {
+				t.Fatal("class missing:", string(line), string(class))
+			}
+		}
+		tokens[string(line)] = true
+	}
+	if len(lines) != 10000 || len(tokens) != len(lines) {
+		t.Error("tokens are not unique")
+	}
+}
+
+func TestTokenWhole(t *testing.T) {
+	format := &tTokenFormat{length: 8}
+	if err := format.initClasses("ulds"); err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, newLine := range [][]byte{{'\n'}, {'\r', '\n'}} {
+		for _, sizeFile := range []int{5, 3000, 3001, 9999} {
+			output := generateTokensTest(t, format, sizeFile, newLine)
+			lines := bytes.Fields(output)
+			if len(output) != sizeFile || len(lines) != sizeFile/format.alignment(newLine) {
+				t.Error("wrong size:", len(output), len(lines))
+			}
+			for _, line := range lines {
+				if len(line) != 8 {
+					t.Fatal("token truncated:", string(line))
+				}
+				for _, class := range format.classes {
+					if !bytes.ContainsAny(line, string(class)) {
+						t.Fatal("class missing:", string(line), string(class))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTokenUnique(t *testing.T) {
+	format := &tTokenFormat{length: 4, alphabet: []byte("0123456789abcdef")}
+	output := generateTokensTest(t, format, 5*65536, []byte{'\n'})
+	tokens := make(map[string]bool)
+	for _, line := range bytes.Fields(output) {
+		tokens[string(line)] = true
+	}
+	if len(tokens) != 65536 {
+		t.Error("tokens are not unique:", len(tokens))
+	}
+	if err := initTokens(&tContent{format: format}, 5*65537, []byte{'\n'}, nil); err == nil {
+		t.Error("no error for too many tokens")
+	}
+}
+
+func TestTokenAlphabet(t *testing.T) {
+	if digits := string(tokenAlphabet("d")); digits != "0123456789" {
+		t.Error("wrong digits:", digits)
+	}
+	for _, b := range tokenAlphabet("s") {
+		if b >= '0' && b <= '9' || b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' {
+			t.Error("wrong symbol:", string(b))
+		}
+	}
+}
+
+func TestTokenCrypto(t *testing.T) {
+	format := &tTokenFormat{prefix: "k_", length: 12, alphabet: []byte("0123456789abcdef"), crypto: true}
+	output := generateTokensTest(t, format, 15*20000, []byte{'\n'})
+	tokens := make(map[string]bool)
+	lines := bytes.Fields(output)
+	for _, line := range lines {
+		if len(line) != 14 || !bytes.HasPrefix(line, []byte("k_")) || len(bytes.Trim(line[2:], "0123456789abcdef")) > 0 {
+			t.Fatal("wrong token:", string(line))
+		}
+		tokens[string(line)] = true
+	}
+	if len(tokens) != len(lines) {
+		t.Error("tokens are not unique")
+	}
+}
+
+// generateTokensTest returns tokens generated in buffers of 100 lines.
+func generateTokensTest(t *testing.T, format *tTokenFormat, sizeFile int, newLine []byte) []byte {
+	content := &tContent{seed: 1, format: format}
+	if err := initTokens(content, sizeFile, newLine, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	return generateTest(format, sizeFile, alignBuffer(format, 1000, newLine), newLine)
+}
@@ -0,0 +1,298 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sortNONE    = ""
+	sortBYTES   = "bytes"
+	sortFOLD    = "fold"
+	sortNATURAL = "natural"
+)
+
+const (
+	uniqueLENGTHS    = 256
+	uniqueSUFFIX_MIN = 8
+	uniqueSUFFIX_MAX = 120
+)
+
+// tUnique writes lines, that start with a unique key. Line i has key i,
+// i.e. lines are sorted by key, or a permutation of i, if lines are not
+// sorted. The key is followed by random words.
+//
+// Lengths of lines repeat a pattern, so the line at an offset is known
+// without generating previous lines. The first line is longer by extra
+// bytes, so the last line ends at the end of output. Each line has its own
+// random numbers, because buffers may split lines.
+type tUnique struct {
+	sort     string
+	reverse  bool
+	alphabet []byte
+	width    int
+	keys     tPermutation
+	lines    int
+	extra    int
+	suffixes []int
+	lengths  []int
+	starts   []int
+	period   int
+	seed     uint64
+}
+
+func interpretUnique(params *tParameters, content *tContent, sizeFile int, newLine []byte, err error) (tFormat, error) {
+	if err == nil && (params.unique.Available() || params.sort.Available()) {
+		if _, ok := content.format.(*tText); !ok {
+			return nil, errors.New("unique and sort need text output")
+		}
+		format := new(tUnique)
+		format.sort = sortNONE
+		if params.sort.Available() {
+			format.sort = strings.ToLower(params.sort.Values[0])
+			if format.sort != sortBYTES && format.sort != sortFOLD && format.sort != sortNATURAL {
+				return nil, errors.New("unknown sort \"" + params.sort.Values[0] + "\" (expected bytes, fold or natural)")
+			}
+			format.reverse = params.reverse.Available()
+		} else if params.reverse.Available() {
+			return nil, errors.New("reverse needs sort")
+		}
+		format.alphabet = alphabetOf(content.randomFill)
+		format.init(content.seed, sizeFile, newLine)
+		return format, nil
+	} else if err == nil && params.reverse.Available() {
+		return nil, errors.New("reverse needs sort")
+	}
+	return content.format, err
+}
+
+// init sets the pattern of lengths and the width of keys, that is large
+// enough for the number of lines.
+func (format *tUnique) init(seed int64, total int, newLine []byte) {
+	random := rand.New(rand.NewSource(seed))
+	format.seed = uint64(seed)
+	format.suffixes = make([]int, uniqueLENGTHS)
+	for i := range format.suffixes {
+		format.suffixes[i] = uniqueSUFFIX_MIN + random.Intn(uniqueSUFFIX_MAX-uniqueSUFFIX_MIN+1)
+	}
+	for format.width = 1; ; format.width++ {
+		format.layout(total, newLine)
+		capacity := uniqueCapacity(format.base(), format.width)
+		if uint64(format.lines) <= capacity {
+			format.keys = newPermutation(random, capacity)
+			break
+		}
+	}
+}
+
+// layout sets lengths of lines with keys of width, the number of lines and
+// the extra length of the first line.
+func (format *tUnique) layout(total int, newLine []byte) {
+	format.lengths = make([]int, len(format.suffixes))
+	format.starts = make([]int, len(format.suffixes))
+	format.period = 0
+	for i, suffix := range format.suffixes {
+		format.lengths[i] = format.width + 1 + suffix + len(newLine)
+		format.starts[i] = format.period
+		format.period += format.lengths[i]
+	}
+	rest := total % format.period
+	index := sort.SearchInts(format.starts, rest+1) - 1
+	format.lines = total/format.period*len(format.lengths) + index
+	format.extra = rest - format.starts[index]
+	if format.lines == 0 {
+		// output is shorter than one line
+		format.lines = 1
+		format.extra = total - format.lengths[0]
+	}
+}
+
+func (format *tUnique) base() int {
+	switch format.sort {
+	case sortFOLD:
+		return 26
+	case sortNATURAL:
+		return 10
+	}
+	return len(format.alphabet)
+}
+
+// uniqueCapacity returns base^width, or the maximum, if it overflows.
+func uniqueCapacity(base, width int) uint64 {
+	capacity := uint64(1)
+	for i := 0; i < width; i++ {
+		if capacity > math.MaxUint64/uint64(base) {
+			return math.MaxUint64
+		}
+		capacity *= uint64(base)
+	}
+	return capacity
+}
+
+// locateLine returns the index and the offset of the line at offset.
+func (format *tUnique) locateLine(offset int) (int, int) {
+	if offset < format.lengths[0]+format.extra {
+		return 0, 0
+	}
+	offset -= format.extra
+	index := sort.SearchInts(format.starts, offset%format.period+1) - 1
+	line := offset/format.period*len(format.lengths) + index
+	return line, format.extra + offset/format.period*format.period + format.starts[index]
+}
+
+func (format *tUnique) lineLength(line int) int {
+	if line == 0 {
+		return format.lengths[0] + format.extra
+	}
+	return format.lengths[line%len(format.lengths)]
+}
+
+// key returns the number, that is encoded as key of line.
+func (format *tUnique) key(line int) uint64 {
+	value := uint64(line)
+	if format.sort == sortNONE {
+		// reversed digits hide, that consecutive keys have equal distance
+		value = format.keys.permute(value)
+		value = format.keys.permute(format.reverseDigits(value))
+	} else if format.reverse && format.sort == sortNATURAL {
+		value = uint64(format.lines-1) - value
+	} else if format.reverse {
+		value = format.keys.capacity - 1 - value
+	}
+	return value
+}
+
+func (format *tUnique) reverseDigits(value uint64) uint64 {
+	var reversed uint64
+	base := uint64(format.base())
+	for i := 0; i < format.width; i++ {
+		reversed = reversed*base + value%base
+		value /= base
+	}
+	return reversed
+}
+
+func (format *tUnique) fill(generator *tGenerator, newLine []byte) {
+	line, start := format.locateLine(generator.offset)
+	for start < generator.offset+len(generator.bytes) && line < format.lines {
+		generator.record = format.appendLine(generator.record[:0], line, newLine)
+		from := generator.offset - start
+		if from < 0 {
+			from = 0
+		}
+		copy(generator.bytes[start+from-generator.offset:], generator.record[from:])
+		start += len(generator.record)
+		line++
+	}
+	generator.written = len(generator.bytes)
+}
+
+// appendLine appends key, space, random words and new line. Line is
+// truncated, if output is shorter than one line.
+func (format *tUnique) appendLine(dst []byte, line int, newLine []byte) []byte {
+	random := tSplitMix{format.seed ^ uint64(line)*0x9e3779b97f4a7c15}
+	length := format.lineLength(line)
+	lengthDst := len(dst)
+	dst = format.appendKey(dst, format.key(line), &random)
+	dst = append(dst, ' ')
+	space := wordLEN_MIN + int(random.next()%(wordLEN_MAX-wordLEN_MIN+1))
+	for i := len(dst) - lengthDst + len(newLine); i < length-1; i++ {
+		if space--; space == 0 {
+			dst = append(dst, ' ')
+			space = wordLEN_MIN + int(random.next()%(wordLEN_MAX-wordLEN_MIN+1))
+		} else {
+			dst = append(dst, format.alphabet[random.next()%uint64(len(format.alphabet))])
+		}
+	}
+	if len(dst)-lengthDst+len(newLine) < length {
+		dst = append(dst, format.alphabet[random.next()%uint64(len(format.alphabet))])
+	}
+	dst = append(dst, newLine...)
+	if len(dst)-lengthDst > length && length >= 0 {
+		return dst[:lengthDst+length]
+	}
+	return dst
+}
+
+// appendKey appends value as number (natural), as letters of random case
+// (fold) or as characters of alphabet. Keys are padded to width, except
+// numbers.
+func (format *tUnique) appendKey(dst []byte, value uint64, random *tSplitMix) []byte {
+	if format.sort == sortNATURAL {
+		return strconv.AppendUint(dst, value, 10)
+	}
+	base := uint64(format.base())
+	lengthDst := len(dst)
+	for i := 0; i < format.width; i++ {
+		dst = append(dst, 0)
+	}
+	for i := len(dst) - 1; i >= lengthDst; i-- {
+		digit := value % base
+		value /= base
+		if format.sort == sortFOLD {
+			dst[i] = byte('a'+digit) - byte(random.next()&1)*('a'-'A')
+		} else {
+			dst[i] = format.alphabet[digit]
+		}
+	}
+	return dst
+}
+
+// tPermutation is an affine permutation of the numbers in [0, capacity).
+type tPermutation struct {
+	capacity   uint64
+	multiplier uint64
+	increment  uint64
+}
+
+func newPermutation(random *rand.Rand, capacity uint64) tPermutation {
+	var permutation tPermutation
+	permutation.capacity = capacity
+	permutation.increment = random.Uint64() % capacity
+	// multiplier is coprime to capacity, so values are a permutation
+	for permutation.multiplier = random.Uint64() | 1; gcd64(permutation.multiplier, capacity) != 1; {
+		permutation.multiplier = random.Uint64() | 1
+	}
+	return permutation
+}
+
+// permute returns value*multiplier+increment modulo capacity.
+func (permutation *tPermutation) permute(value uint64) uint64 {
+	hi, lo := bits.Mul64(value, permutation.multiplier)
+	lo, carry := bits.Add64(lo, permutation.increment, 0)
+	_, remainder := bits.Div64((hi+carry)%permutation.capacity, lo, permutation.capacity)
+	return remainder
+}
+
+// tSplitMix is a fast generator of random numbers (SplitMix64), that is
+// seeded for each line.
+type tSplitMix struct {
+	state uint64
+}
+
+func (random *tSplitMix) next() uint64 {
+	random.state += 0x9e3779b97f4a7c15
+	z := random.state
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+func gcd64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,72 @@
+/*
+ *          Copyright 2022, Vitali Baumtrok.
+ * Distributed under the Boost Software License, Version 1.0.
+ *     (See accompanying file LICENSE or copy at
+ *        http://www.boost.org/LICENSE_1_0.txt)
+ */
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+)
+
+func TestUniqueSorted(t *testing.T) {
+	less := map[string]func(a, b []byte) bool{
+		sortBYTES: func(a, b []byte) bool {
+			return bytes.Compare(a, b) < 0
+		},
+		sortFOLD: func(a, b []byte) bool {
+			return bytes.Compare(bytes.ToUpper(a), bytes.ToUpper(b)) < 0
+		},
+		sortNATURAL: func(a, b []byte) bool {
+			numberA, _ := strconv.Atoi(string(a[:bytes.IndexByte(a, ' ')]))
+			numberB, _ := strconv.Atoi(string(b[:bytes.IndexByte(b, ' ')]))
+			return numberA < numberB
+		}}
+	for _, reverse := range []bool{false, true} {
+		for mode, lessMode := range less {
+			lines := generateUniqueTest(t, &tUnique{sort: mode, reverse: reverse}, 200003, []byte{'\r', '\n'})
+			for i := 1; i < len(lines); i++ {
+				if lessMode(lines[i-1], lines[i]) == reverse {
+					t.Fatal(mode, "not sorted:", string(lines[i-1]), string(lines[i]))
+				}
+			}
+		}
+	}
+}
+
+func TestUniqueUnsorted(t *testing.T) {
+	for _, size := range []int{1, 10, 100, 1000, 100000} {
+		format := &tUnique{sort: sortNONE}
+		lines := generateUniqueTest(t, format, size, []byte{'\n'})
+		if len(lines) != format.lines {
+			t.Error("wrong number of lines:", len(lines), format.lines)
+		}
+	}
+}
+
+// generateUniqueTest returns lines of output and checks size and
+// uniqueness.
+func generateUniqueTest(t *testing.T, format *tUnique, size int, newLine []byte) [][]byte {
+	format.alphabet = alphabetOf(randomFillZ)
+	format.init(1, size, newLine)
+	output := generateTest(format, size, 997, newLine)
+	if len(output) != size {
+		t.Error("wrong size:", len(output))
+	}
+	if size > 100 && !bytes.HasSuffix(output, newLine) {
+		t.Error("last line is truncated")
+	}
+	lines := bytes.Split(bytes.TrimSuffix(output, newLine), newLine)
+	keys := make(map[string]bool)
+	for _, line := range lines {
+		keys[string(line)] = true
+	}
+	if len(keys) != len(lines) {
+		t.Error("lines are not unique:", len(lines)-len(keys))
+	}
+	return lines
+}